@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testSecret = "test secret"
+
+func signToken(t *testing.T, secret string, header string, claims tokenClaims) string {
+	payload, err := json.Marshal(claims)
+	require.Nil(t, err)
+	signed := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func testToken(t *testing.T, roles ...string) string {
+	return signToken(t, testSecret, `{"alg":"HS256","typ":"JWT"}`, tokenClaims{
+		Subject:  "alice",
+		TenantID: "acme",
+		Roles:    roles,
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+func (f httpHandlerFixture) expectIdentity(t *testing.T, tenant string, user string, roles string) {
+	f.orders.mockHandler.EXPECT().
+		ServeHTTP(gomock.Any(), gomock.Any()).
+		Do(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, tenant, r.Header.Get(tenantHeader))
+			assert.Equal(t, user, r.Header.Get(userIDHeader))
+			assert.Equal(t, roles, r.Header.Get(userRolesHeader))
+			w.WriteHeader(http.StatusNoContent)
+		})
+}
+
+func TestHttpHandler_DropsIdentityHeadersOfClient(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.expectIdentity(t, "", "", "")
+
+	request := httptest.NewRequest("GET", "/orders", nil)
+	request.Header.Set(tenantHeader, "acme")
+	request.Header.Set(userIDHeader, "mallory")
+	request.Header.Set(userRolesHeader, "admin")
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusNoContent, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_SetsIdentityHeadersFromToken(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.expectIdentity(t, "acme", "alice", "support,admin")
+
+	request := httptest.NewRequest("GET", "/orders", nil)
+	request.Header.Set("Authorization", "Bearer "+testToken(t, "support", "admin"))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusNoContent, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ReplacesIdentityHeadersOfClientWithToken(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.expectIdentity(t, "acme", "alice", "")
+
+	request := httptest.NewRequest("GET", "/orders", nil)
+	request.Header.Set("Authorization", "Bearer "+testToken(t))
+	request.Header.Set(tenantHeader, "umbrella")
+	request.Header.Set(userIDHeader, "mallory")
+	request.Header.Set(userRolesHeader, "admin")
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusNoContent, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_RejectsInvalidToken(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Unix()
+	claims := tokenClaims{Subject: "alice", TenantID: "acme", Roles: []string{"admin"}, Expires: expires}
+	header := `{"alg":"HS256","typ":"JWT"}`
+	data := []struct {
+		name          string
+		authorization func(t *testing.T) string
+	}{
+		{"NotBearer", func(t *testing.T) string {
+			return "Basic YWxpY2U6c2VjcmV0"
+		}},
+		{"Malformed", func(t *testing.T) string {
+			return "Bearer not a token"
+		}},
+		{"WrongSecret", func(t *testing.T) string {
+			return "Bearer " + signToken(t, "other secret", header, claims)
+		}},
+		{"UnsignedAlgorithm", func(t *testing.T) string {
+			return "Bearer " + signToken(t, testSecret, `{"alg":"none"}`, claims)
+		}},
+		{"Expired", func(t *testing.T) string {
+			expired := claims
+			expired.Expires = time.Now().Add(-time.Minute).Unix()
+			return "Bearer " + signToken(t, testSecret, header, expired)
+		}},
+		{"MissingExpiry", func(t *testing.T) string {
+			unlimited := claims
+			unlimited.Expires = 0
+			return "Bearer " + signToken(t, testSecret, header, unlimited)
+		}},
+		{"MissingTenant", func(t *testing.T) string {
+			withoutTenant := claims
+			withoutTenant.TenantID = ""
+			return "Bearer " + signToken(t, testSecret, header, withoutTenant)
+		}},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+
+			request := httptest.NewRequest("GET", "/orders", nil)
+			request.Header.Set("Authorization", d.authorization(t))
+			request.Header.Set(userRolesHeader, "admin")
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusUnauthorized, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestAuthenticator_RejectsEveryTokenWithoutSecret(t *testing.T) {
+	_, err := newAuthenticator("").verify(signToken(t, "", `{"alg":"HS256"}`, tokenClaims{
+		Subject:  "alice",
+		TenantID: "acme",
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	}))
+
+	assert.ErrorIs(t, err, errInvalidToken)
+}
@@ -22,14 +22,17 @@ func (h httpHandlerMux) ServeHTTP(responseWriter http.ResponseWriter, request *h
 
 type httpHandlerMuxConfig struct {
 	logger *slog.Logger
+	auth   authenticator
 	orders serverConfig
 }
 
 func newHttpHandlerMux(config httpHandlerMuxConfig) httpHandlerMux {
 	mux := http.NewServeMux()
-	ordersHandler := httpHandler{config.logger, handleOrders, config.orders}
+	ordersHandler := httpHandler{config.logger, config.auth, handleOrders, config.orders}
 	mux.Handle("/orders", ordersHandler)
 	mux.Handle("/orders/", ordersHandler)
+	mux.Handle("/orders:batch", ordersHandler)
+	mux.Handle("/orders:import", ordersHandler)
 	return httpHandlerMux{mux}
 }
 
@@ -37,12 +40,19 @@ type handleFunc func(*slog.Logger, serverConfig, http.ResponseWriter, *http.Requ
 
 type httpHandler struct {
 	logger *slog.Logger
+	auth   authenticator
 	handle handleFunc
 	server serverConfig
 }
 
 func (h httpHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
-	h.handle(h.logger, h.server, responseWriter, request)
+	authenticated, err := h.auth.authenticate(request)
+	if err != nil {
+		h.logger.Info("rejected request with invalid token", "method", request.Method, "url", request.URL.String())
+		http.Error(responseWriter, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.handle(h.logger, h.server, responseWriter, authenticated)
 }
 
 func handleOrders(logger *slog.Logger, server serverConfig, responseWriter http.ResponseWriter, request *http.Request) {
@@ -54,6 +64,7 @@ func handleOrders(logger *slog.Logger, server serverConfig, responseWriter http.
 		return
 	}
 	proxyURL.Path = request.URL.Path
+	proxyURL.RawQuery = request.URL.RawQuery
 	proxyRequest, err := http.NewRequest(request.Method, proxyURL.String(), request.Body)
 	if err != nil {
 		logger.Error("failed to create http request", "error", err.Error(), "method", request.Method, "url", proxyURL.String())
@@ -33,6 +33,7 @@ func setUpHttpHandlerTest(t *testing.T) httpHandlerFixture {
 	t.Cleanup(ordersServer.Close)
 	mux := newHttpHandlerMux(httpHandlerMuxConfig{
 		logger: logger,
+		auth:   newAuthenticator(testSecret),
 		orders: serverConfig{ordersServer.URL, ordersServer.Client()},
 	})
 	return httpHandlerFixture{
@@ -138,6 +139,16 @@ func TestHttpHandler_ForwardsRequestMethodAndPathToOrders(t *testing.T) {
 			"GET",
 			"/orders/111",
 		},
+		{
+			"PostBatch",
+			"POST",
+			"/orders:batch",
+		},
+		{
+			"PostImport",
+			"POST",
+			"/orders:import",
+		},
 	}
 
 	for _, d := range data {
@@ -158,6 +169,50 @@ func TestHttpHandler_ForwardsRequestMethodAndPathToOrders(t *testing.T) {
 	}
 }
 
+func TestHttpHandler_ForwardsQueryToOrders(t *testing.T) {
+	data := []struct {
+		name   string
+		target string
+		query  string
+	}{
+		{
+			"ListOrders",
+			"/orders",
+			"cursor=abc&limit=10&sort=-created_at&status=paid&created_from=2026-01-01T00%3A00%3A00Z&title=duck",
+		},
+		{
+			"RepeatedParameter",
+			"/orders",
+			"status=created&status=paid",
+		},
+		{
+			"NoQuery",
+			"/orders/111",
+			"",
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+
+			f.orders.mockHandler.EXPECT().
+				ServeHTTP(gomock.Any(), gomock.Any()).
+				Do(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, d.target, r.URL.Path)
+					assert.Equal(t, d.query, r.URL.RawQuery)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+				})
+
+			target := d.target
+			if d.query != "" {
+				target += "?" + d.query
+			}
+			request := httptest.NewRequest("GET", target, nil)
+			f.mux.ServeHTTP(f.responseRecorder, request)
+		})
+	}
+}
+
 func TestHttpHandler_ForwardsRequestBodyToOrders(t *testing.T) {
 	data := []struct {
 		body string
@@ -255,3 +310,41 @@ func TestHttpHandler_ReturnsOtherHeaderFromOrders(t *testing.T) {
 	assert.Equal(t, "bazinga", f.responseRecorder.Header().Get("X-Bar"))
 	assert.Equal(t, "quantum physics", f.responseRecorder.Header().Get("X-Fizz"))
 }
+
+func TestHttpHandler_ForwardsSearchQueryToOrders(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.orders.mockHandler.EXPECT().
+		ServeHTTP(gomock.Any(), gomock.Any()).
+		Do(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/orders/search", r.URL.Path)
+			assert.Equal(t, "rubber duck", r.URL.Query().Get("q"))
+			assert.Equal(t, "abc", r.URL.Query().Get("cursor"))
+			assert.Equal(t, "5", r.URL.Query().Get("limit"))
+			w.WriteHeader(http.StatusOK)
+		})
+
+	request := httptest.NewRequest("GET", "/orders/search?q=rubber+duck&cursor=abc&limit=5", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ForwardsExportFiltersToOrders(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.orders.mockHandler.EXPECT().
+		ServeHTTP(gomock.Any(), gomock.Any()).
+		Do(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/orders/export", r.URL.Path)
+			assert.Equal(t, "ndjson", r.URL.Query().Get("format"))
+			assert.Equal(t, "paid", r.URL.Query().Get("status"))
+			assert.Equal(t, "2026-01-01T00:00:00Z", r.URL.Query().Get("created_from"))
+			assert.Equal(t, "2026-02-01T00:00:00Z", r.URL.Query().Get("created_to"))
+			w.WriteHeader(http.StatusOK)
+		})
+
+	request := httptest.NewRequest("GET",
+		"/orders/export?format=ndjson&status=paid&created_from=2026-01-01T00%3A00%3A00Z&created_to=2026-02-01T00%3A00%3A00Z", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+}
@@ -9,8 +9,13 @@ import (
 
 func main() {
 	logger := createLogger()
+	secret := os.Getenv("AUTH_TOKEN_SECRET")
+	if secret == "" {
+		logger.Warn("AUTH_TOKEN_SECRET is not set, all bearer tokens are rejected")
+	}
 	handler := newHttpHandlerMux(httpHandlerMuxConfig{
 		logger: logger,
+		auth:   newAuthenticator(secret),
 		orders: serverConfig{},
 	})
 	server := http.Server{
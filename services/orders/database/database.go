@@ -1,16 +1,20 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 	"log/slog"
 	"net/url"
+	"strconv"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/mrstecklo/micropet/services/orders/orders"
 )
 
-var ErrNotFound = errors.New("not found")
+var ErrNotFound = orders.ErrNotFound
 
 type Database struct {
 	db     *sql.DB
@@ -25,44 +29,400 @@ func (db Database) Close() {
 	}
 }
 
-func (db Database) GetOrder(id int) (orders.Order, error) {
+// beginTx starts a transaction scoped to the tenant. Besides the explicit
+// tenant_id conditions of the queries, the setting is checked by the
+// row-level security policies of the schema where they are enforced.
+func (db Database) beginTx(ctx context.Context, tenant string) (*sql.Tx, error) {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, true)", tenant)
+	if err != nil {
+		db.rollback(tx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (db Database) GetOrder(ctx context.Context, tenant string, id int) (orders.Order, error) {
+	tx, err := db.beginTx(ctx, tenant)
+	if err != nil {
+		return orders.Order{}, err
+	}
+	defer db.rollback(tx)
 	var order orders.Order
-	err := db.db.QueryRow("SELECT id, title FROM orders WHERE id = $1", id).
-		Scan(&order.ID, &order.Title)
+	err = scanOrder(tx.QueryRowContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE tenant_id = $1 AND id = $2", tenant, id), &order)
 	if err == sql.ErrNoRows {
 		return order, ErrNotFound
 	}
-	return order, err
+	if err != nil {
+		return order, err
+	}
+	items, err := getOrderItems(ctx, tx, []int{id})
+	if err != nil {
+		return order, err
+	}
+	order.Items = items[id]
+	return order, tx.Commit()
+}
+
+const orderColumns = "id, tenant_id, customer_id, title, status, total_amount, currency, created_at, version"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row scanner, order *orders.Order) error {
+	err := row.Scan(&order.ID, &order.TenantID, &order.CustomerID, &order.Title, &order.Status, &order.Total.Amount, &order.Total.Currency,
+		&order.CreatedAt, &order.Version)
+	order.CreatedAt = order.CreatedAt.UTC()
+	return err
+}
+
+func getOrderItems(ctx context.Context, tx *sql.Tx, ids []int) (map[int][]orders.Item, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT order_id, sku, quantity, unit_price_amount, currency FROM order_items WHERE order_id = ANY($1) ORDER BY order_id, position",
+		ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make(map[int][]orders.Item)
+	for rows.Next() {
+		var id int
+		var item orders.Item
+		err = rows.Scan(&id, &item.SKU, &item.Quantity, &item.UnitPrice.Amount, &item.UnitPrice.Currency)
+		if err != nil {
+			return nil, err
+		}
+		items[id] = append(items[id], item)
+	}
+	return items, rows.Err()
+}
+
+func (db Database) CreateOrder(ctx context.Context, order orders.Order) (int, error) {
+	tx, err := db.beginTx(ctx, order.TenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer db.rollback(tx)
+	id, err := insertOrder(ctx, tx, order)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
 }
 
-func (db Database) CreateOrder(title string) (int, error) {
+func (db Database) CreateOrders(ctx context.Context, created []orders.Order) ([]int, error) {
+	if len(created) == 0 {
+		return nil, nil
+	}
+	tx, err := db.beginTx(ctx, created[0].TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.rollback(tx)
+	ids := make([]int, len(created))
+	for idx, order := range created {
+		ids[idx], err = insertOrder(ctx, tx, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, tx.Commit()
+}
+
+func (db Database) CreateOrderWithKey(ctx context.Context, order orders.Order, key orders.IdempotencyKey) (int, bool, error) {
+	tx, err := db.beginTx(ctx, order.TenantID)
+	if err != nil {
+		return 0, false, err
+	}
+	defer db.rollback(tx)
+	_, err = tx.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE tenant_id = $1 AND key = $2 AND expires_at <= now()",
+		order.TenantID, key.Key)
+	if err != nil {
+		return 0, false, err
+	}
+	// A concurrent transaction inserting the same key makes this statement
+	// wait until it commits, so only one of them creates the order.
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO idempotency_keys (tenant_id, key, fingerprint, expires_at) VALUES ($1, $2, $3, now() + make_interval(secs => $4)) ON CONFLICT (tenant_id, key) DO NOTHING",
+		order.TenantID, key.Key, key.Fingerprint, key.TTL.Seconds())
+	if err != nil {
+		return 0, false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+	if inserted == 0 {
+		var fingerprint string
+		var id int
+		err = tx.QueryRowContext(ctx, "SELECT fingerprint, order_id FROM idempotency_keys WHERE tenant_id = $1 AND key = $2",
+			order.TenantID, key.Key).
+			Scan(&fingerprint, &id)
+		if err != nil {
+			return 0, false, err
+		}
+		if fingerprint != key.Fingerprint {
+			return 0, false, orders.ErrIdempotencyKeyReused
+		}
+		return id, false, nil
+	}
+	id, err := insertOrder(ctx, tx, order)
+	if err != nil {
+		return 0, false, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE idempotency_keys SET order_id = $3 WHERE tenant_id = $1 AND key = $2",
+		order.TenantID, key.Key, id)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, tx.Commit()
+}
+
+// DeleteExpiredIdempotencyKeys runs outside of a tenant, the row-level
+// security policies let it see and delete expired keys only.
+func (db Database) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := db.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= now()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func insertOrder(ctx context.Context, tx *sql.Tx, order orders.Order) (int, error) {
 	var id int
-	err := db.db.QueryRow("INSERT INTO orders (title) VALUES ($1) RETURNING id", title).
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO orders (tenant_id, customer_id, title, status, total_amount, currency, created_at, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		order.TenantID, order.CustomerID, order.Title, order.Status, order.Total.Amount, order.Total.Currency, order.CreatedAt, order.Version).
 		Scan(&id)
-	return id, err
+	if err != nil {
+		return 0, err
+	}
+	err = insertOrderItems(ctx, tx, id, order.Items)
+	if err != nil {
+		return 0, err
+	}
+	order.ID = id
+	err = insertEvent(ctx, tx, orders.EventOrderCreated, order, "")
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func insertOrderItems(ctx context.Context, tx *sql.Tx, id int, items []orders.Item) error {
+	for position, item := range items {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO order_items (order_id, position, sku, quantity, unit_price_amount, currency) VALUES ($1, $2, $3, $4, $5, $6)",
+			id, position, item.SKU, item.Quantity, item.UnitPrice.Amount, item.UnitPrice.Currency)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db Database) rollback(tx *sql.Tx) {
+	err := tx.Rollback()
+	if err != nil && err != sql.ErrTxDone {
+		db.logger.Error("failed to roll back transaction", "error", err.Error())
+	}
+}
+
+func (db Database) UpdateOrderStatus(ctx context.Context, order orders.Order, previous orders.Status, version int) error {
+	tx, err := db.beginTx(ctx, order.TenantID)
+	if err != nil {
+		return err
+	}
+	defer db.rollback(tx)
+	result, err := tx.ExecContext(ctx,
+		"UPDATE orders SET status = $4, version = $5 WHERE tenant_id = $1 AND id = $2 AND status = $3 AND version = $6",
+		order.TenantID, order.ID, previous, order.Status, order.Version, version)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var current orders.Status
+		err = tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE tenant_id = $1 AND id = $2", order.TenantID, order.ID).
+			Scan(&current)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if current != previous {
+			return orders.TransitionError{From: current, To: order.Status}
+		}
+		return orders.ErrVersionMismatch
+	}
+	err = insertEvent(ctx, tx, orders.EventOrderStatusChanged, order, previous)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db Database) UpdateOrder(ctx context.Context, order orders.Order, version int) error {
+	tx, err := db.beginTx(ctx, order.TenantID)
+	if err != nil {
+		return err
+	}
+	defer db.rollback(tx)
+	result, err := tx.ExecContext(ctx,
+		"UPDATE orders SET title = $4, total_amount = $5, currency = $6, version = $7 WHERE tenant_id = $1 AND id = $2 AND version = $3",
+		order.TenantID, order.ID, version, order.Title, order.Total.Amount, order.Total.Currency, order.Version)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var exists bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM orders WHERE tenant_id = $1 AND id = $2)",
+			order.TenantID, order.ID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return orders.ErrVersionMismatch
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM order_items WHERE order_id = $1", order.ID)
+	if err != nil {
+		return err
+	}
+	err = insertOrderItems(ctx, tx, order.ID, order.Items)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db Database) Clear() error {
-	_, err := db.db.Exec("DELETE FROM orders")
+	_, err := db.db.Exec("DELETE FROM outbox")
+	if err != nil {
+		return err
+	}
+	_, err = db.db.Exec("DELETE FROM order_events")
+	if err != nil {
+		return err
+	}
+	_, err = db.db.Exec("DELETE FROM orders")
 	return err
 }
 
-func NewDatabase(dsn string, logger *slog.Logger) (Database, error) {
-	safeDsn, err := hideUrlAuthority(dsn)
+type Config struct {
+	DSN    string
+	Logger *slog.Logger
+	// Migrate applies pending schema migrations when the database is opened.
+	Migrate bool
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout aborts statements running longer, zero disables it.
+	StatementTimeout time.Duration
+
+	// PingAttempts is how many times the database is pinged before
+	// NewDatabase gives up, waiting PingBackoff after the first failure and
+	// twice as long after each next one, up to MaxPingBackoff.
+	PingAttempts   int
+	PingTimeout    time.Duration
+	PingBackoff    time.Duration
+	MaxPingBackoff time.Duration
+}
+
+func NewDatabase(ctx context.Context, config Config) (Database, error) {
+	if config.MaxOpenConns == 0 {
+		config.MaxOpenConns = 10
+	}
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = config.MaxOpenConns
+	}
+	if config.ConnMaxLifetime == 0 {
+		config.ConnMaxLifetime = 30 * time.Minute
+	}
+	if config.ConnMaxIdleTime == 0 {
+		config.ConnMaxIdleTime = 5 * time.Minute
+	}
+	if config.PingAttempts == 0 {
+		config.PingAttempts = 5
+	}
+	if config.PingTimeout == 0 {
+		config.PingTimeout = 5 * time.Second
+	}
+	if config.PingBackoff == 0 {
+		config.PingBackoff = 500 * time.Millisecond
+	}
+	if config.MaxPingBackoff == 0 {
+		config.MaxPingBackoff = 5 * time.Second
+	}
+	logger := config.Logger
+	safeDsn, err := hideUrlAuthority(config.DSN)
 	if err != nil {
-		logger.Error("failed to parse database URL", "error", err.Error())
-		return Database{}, nil
+		return Database{}, fmt.Errorf("invalid database URL: %w", err)
 	}
-	logger.Info("opening database", "url", safeDsn)
-	db, err := sql.Open("pgx", dsn)
+	connConfig, err := pgx.ParseConfig(config.DSN)
 	if err != nil {
-		logger.Error("failed to open database", "url", safeDsn)
-		return Database{}, err
+		return Database{}, fmt.Errorf("invalid database URL %s: %w", safeDsn, err)
+	}
+	if config.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.StatementTimeout.Milliseconds(), 10)
 	}
-	return Database{
+	logger.Info("opening database", "url", safeDsn)
+	db := stdlib.OpenDB(*connConfig)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	database := Database{
 		db:     db,
 		logger: logger,
-	}, nil
+	}
+	err = database.ping(ctx, config)
+	if err != nil {
+		database.Close()
+		return Database{}, fmt.Errorf("database %s is unreachable: %w", safeDsn, err)
+	}
+	if config.Migrate {
+		err = database.Migrate(ctx)
+		if err != nil {
+			database.Close()
+			return Database{}, err
+		}
+	}
+	return database, nil
+}
+
+func (db Database) ping(ctx context.Context, config Config) error {
+	backoff := config.PingBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, config.PingTimeout)
+		err := db.db.PingContext(pingCtx)
+		cancel()
+		if err == nil || attempt >= config.PingAttempts {
+			return err
+		}
+		db.logger.Warn("failed to ping database", "attempt", attempt, "retry_in", backoff, "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, config.MaxPingBackoff)
+	}
 }
 
 func hideUrlAuthority(input string) (string, error) {
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type moneyPayload struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type itemPayload struct {
+	SKU       string       `json:"sku"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice moneyPayload `json:"unit_price"`
+}
+
+type orderPayload struct {
+	ID         int           `json:"id"`
+	TenantID   string        `json:"tenant_id"`
+	CustomerID string        `json:"customer_id"`
+	Title      string        `json:"title"`
+	Status     orders.Status `json:"status"`
+	Items      []itemPayload `json:"items"`
+	Total      moneyPayload  `json:"total"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Version    int           `json:"version"`
+}
+
+type eventPayload struct {
+	Order          orderPayload  `json:"order"`
+	PreviousStatus orders.Status `json:"previous_status,omitempty"`
+}
+
+func newEventPayload(order orders.Order, previous orders.Status) eventPayload {
+	payload := eventPayload{
+		Order: orderPayload{
+			ID:         order.ID,
+			TenantID:   order.TenantID,
+			CustomerID: order.CustomerID,
+			Title:      order.Title,
+			Status:     order.Status,
+			Total:      moneyPayload(order.Total),
+			CreatedAt:  order.CreatedAt,
+			Version:    order.Version,
+		},
+		PreviousStatus: previous,
+	}
+	for _, item := range order.Items {
+		payload.Order.Items = append(payload.Order.Items, itemPayload{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: moneyPayload(item.UnitPrice),
+		})
+	}
+	return payload
+}
+
+func (p eventPayload) event(id int64, eventType orders.EventType) orders.Event {
+	event := orders.Event{
+		ID:   id,
+		Type: eventType,
+		Order: orders.Order{
+			ID:         p.Order.ID,
+			TenantID:   p.Order.TenantID,
+			CustomerID: p.Order.CustomerID,
+			Title:      p.Order.Title,
+			Status:     p.Order.Status,
+			Total:      orders.Money(p.Order.Total),
+			CreatedAt:  p.Order.CreatedAt,
+			Version:    p.Order.Version,
+		},
+		PreviousStatus: p.PreviousStatus,
+	}
+	for _, item := range p.Order.Items {
+		event.Order.Items = append(event.Order.Items, orders.Item{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: orders.Money(item.UnitPrice),
+		})
+	}
+	return event
+}
+
+func insertEvent(ctx context.Context, tx *sql.Tx, eventType orders.EventType, order orders.Order, previous orders.Status) error {
+	payload, err := json.Marshal(newEventPayload(order, previous))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO outbox (event_type, payload) VALUES ($1, $2)", eventType, string(payload))
+	return err
+}
+
+func (db Database) PendingEvents(ctx context.Context, limit int) ([]orders.Event, error) {
+	rows, err := db.db.QueryContext(ctx,
+		"SELECT id, event_type, payload, created_at FROM outbox WHERE delivered_at IS NULL ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []orders.Event
+	for rows.Next() {
+		var id int64
+		var eventType orders.EventType
+		var data []byte
+		var createdAt time.Time
+		err = rows.Scan(&id, &eventType, &data, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		var payload eventPayload
+		err = json.Unmarshal(data, &payload)
+		if err != nil {
+			return nil, err
+		}
+		event := payload.event(id, eventType)
+		event.CreatedAt = createdAt.UTC()
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (db Database) MarkEventDelivered(ctx context.Context, id int64) error {
+	_, err := db.db.ExecContext(ctx, "UPDATE outbox SET delivered_at = now() WHERE id = $1", id)
+	return err
+}
+
+func (db Database) PruneDeliveredEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := db.db.ExecContext(ctx, "DELETE FROM outbox WHERE delivered_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
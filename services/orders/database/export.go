@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// exportFetchSize is the number of orders fetched from the export cursor at
+// once.
+const exportFetchSize = 500
+
+// ExportOrders reads the orders through a server-side cursor, so only
+// exportFetchSize of them are held in memory at a time. The export sees a
+// consistent snapshot, but keeps a transaction open until visit has seen
+// every order.
+func (db Database) ExportOrders(ctx context.Context, tenant string, filter orders.ListFilter, visit func(orders.Order) error) error {
+	tx, err := db.beginTx(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	defer db.rollback(tx)
+	var builder queryBuilder
+	builder.where("tenant_id = " + builder.arg(tenant))
+	builder.filter(filter)
+	_, err = tx.ExecContext(ctx, "DECLARE orders_export NO SCROLL CURSOR FOR SELECT "+orderColumns+" FROM orders"+
+		builder.whereClause()+" ORDER BY id", builder.args...)
+	if err != nil {
+		return err
+	}
+	fetch := "FETCH FORWARD " + strconv.Itoa(exportFetchSize) + " FROM orders_export"
+	for {
+		found, err := queryOrders(ctx, tx, fetch)
+		if err != nil {
+			return err
+		}
+		for _, order := range found {
+			err = visit(order)
+			if err != nil {
+				return err
+			}
+		}
+		if len(found) < exportFetchSize {
+			break
+		}
+	}
+	return tx.Commit()
+}
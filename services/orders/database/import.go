@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// ImportOrders loads the orders, their items and their events with COPY. As
+// COPY does not return generated ids, the ids are taken from the sequence of
+// the orders table beforehand.
+func (db Database) ImportOrders(ctx context.Context, imported []orders.Order) error {
+	if len(imported) == 0 {
+		return nil
+	}
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		return db.copyOrders(ctx, driverConn.(*stdlib.Conn).Conn(), imported)
+	})
+}
+
+func (db Database) copyOrders(ctx context.Context, conn *pgx.Conn, imported []orders.Order) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := tx.Rollback(context.WithoutCancel(ctx))
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			db.logger.Error("failed to roll back transaction", "error", err.Error())
+		}
+	}()
+	_, err = tx.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true)", imported[0].TenantID)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query(ctx, "SELECT nextval(pg_get_serial_sequence('orders', 'id')) FROM generate_series(1, $1)", len(imported))
+	if err != nil {
+		return err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return err
+	}
+	slices.Sort(ids)
+
+	orderRows := make([][]any, len(imported))
+	var itemRows, eventRows [][]any
+	for idx, order := range imported {
+		order.ID = ids[idx]
+		orderRows[idx] = []any{order.ID, order.TenantID, order.CustomerID, order.Title, string(order.Status),
+			order.Total.Amount, order.Total.Currency, order.CreatedAt, order.Version}
+		for position, item := range order.Items {
+			itemRows = append(itemRows, []any{order.ID, position, item.SKU, item.Quantity, item.UnitPrice.Amount, item.UnitPrice.Currency})
+		}
+		payload, err := json.Marshal(newEventPayload(order, ""))
+		if err != nil {
+			return err
+		}
+		eventRows = append(eventRows, []any{string(orders.EventOrderCreated), string(payload)})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"orders"},
+		[]string{"id", "tenant_id", "customer_id", "title", "status", "total_amount", "currency", "created_at", "version"},
+		pgx.CopyFromRows(orderRows))
+	if err != nil {
+		return err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"order_items"},
+		[]string{"order_id", "position", "sku", "quantity", "unit_price_amount", "currency"},
+		pgx.CopyFromRows(itemRows))
+	if err != nil {
+		return err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"outbox"}, []string{"event_type", "payload"}, pgx.CopyFromRows(eventRows))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
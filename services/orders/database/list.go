@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var orderByClauses = map[orders.SortOrder]string{
+	orders.SortByIDAsc:         "id ASC",
+	orders.SortByIDDesc:        "id DESC",
+	orders.SortByCreatedAtAsc:  "created_at ASC, id ASC",
+	orders.SortByCreatedAtDesc: "created_at DESC, id DESC",
+}
+
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (b *queryBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+func (b *queryBuilder) filter(filter orders.ListFilter) {
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for idx, status := range filter.Statuses {
+			statuses[idx] = string(status)
+		}
+		b.where("status = ANY(" + b.arg(statuses) + ")")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		b.where("created_at >= " + b.arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		b.where("created_at < " + b.arg(filter.CreatedTo))
+	}
+	if filter.TitleContains != "" {
+		b.where("title ILIKE " + b.arg("%"+likeEscaper.Replace(filter.TitleContains)+"%"))
+	}
+	if filter.CustomerID != "" {
+		b.where("customer_id = " + b.arg(filter.CustomerID))
+	}
+}
+
+func (b *queryBuilder) after(sort orders.SortOrder, cursor *orders.Cursor) {
+	if cursor == nil {
+		return
+	}
+	switch sort {
+	case orders.SortByIDAsc:
+		b.where("id > " + b.arg(cursor.ID))
+	case orders.SortByIDDesc:
+		b.where("id < " + b.arg(cursor.ID))
+	case orders.SortByCreatedAtAsc:
+		b.where("(created_at, id) > (" + b.arg(cursor.CreatedAt) + ", " + b.arg(cursor.ID) + ")")
+	case orders.SortByCreatedAtDesc:
+		b.where("(created_at, id) < (" + b.arg(cursor.CreatedAt) + ", " + b.arg(cursor.ID) + ")")
+	}
+}
+
+func (db Database) ListOrders(ctx context.Context, tenant string, query orders.ListQuery) ([]orders.Order, error) {
+	tx, err := db.beginTx(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer db.rollback(tx)
+	var builder queryBuilder
+	builder.where("tenant_id = " + builder.arg(tenant))
+	builder.filter(query.Filter)
+	builder.after(query.Sort, query.After)
+	statement := "SELECT " + orderColumns + " FROM orders" + builder.whereClause() +
+		" ORDER BY " + orderByClauses[query.Sort] + " LIMIT " + builder.arg(query.Limit)
+	found, err := queryOrders(ctx, tx, statement, builder.args...)
+	if err != nil {
+		return nil, err
+	}
+	return found, tx.Commit()
+}
+
+// queryOrders runs a statement selecting orderColumns and returns the orders
+// with their items in the order of the rows.
+func queryOrders(ctx context.Context, tx *sql.Tx, statement string, args ...any) ([]orders.Order, error) {
+	rows, err := tx.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var found []orders.Order
+	var ids []int
+	for rows.Next() {
+		var order orders.Order
+		err = scanOrder(rows, &order)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, order)
+		ids = append(ids, order.ID)
+	}
+	err = rows.Err()
+	if err != nil || len(found) == 0 {
+		return found, err
+	}
+	items, err := getOrderItems(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range found {
+		found[idx].Items = items[found[idx].ID]
+	}
+	return found, nil
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// migrationLockID is the key of the advisory lock that serialises migrations
+// run by concurrent instances.
+const migrationLockID = 4_801_772_563
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+var migrations = mustLoadMigrations(migrationFiles)
+
+func mustLoadMigrations(files fs.FS) []migration {
+	loaded, err := loadMigrations(files)
+	if err != nil {
+		panic(err)
+	}
+	return loaded
+}
+
+func loadMigrations(files fs.FS) ([]migration, error) {
+	names, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*migration)
+	for _, name := range names {
+		match := migrationFileName.FindStringSubmatch(path.Base(name))
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: unexpected file name", name)
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if m.name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names %q and %q", version, m.name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+	var loaded []migration
+	for _, m := range byVersion {
+		loaded = append(loaded, *m)
+	}
+	slices.SortFunc(loaded, func(a migration, b migration) int {
+		return a.version - b.version
+	})
+	for idx, m := range loaded {
+		if m.version != idx+1 {
+			return nil, fmt.Errorf("migration %d is missing", idx+1)
+		}
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d must have both up and down files", m.version)
+		}
+	}
+	return loaded, nil
+}
+
+// LatestSchemaVersion returns the version the schema has after Migrate.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// SchemaVersion returns the version of the last applied migration, 0 if
+// none were applied.
+func (db Database) SchemaVersion(ctx context.Context) (int, error) {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	err = createMigrationsTable(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	return schemaVersion(ctx, conn)
+}
+
+// Migrate applies all pending migrations.
+func (db Database) Migrate(ctx context.Context) error {
+	return db.MigrateTo(ctx, LatestSchemaVersion())
+}
+
+// MigrateDown reverts the last steps applied migrations.
+func (db Database) MigrateDown(ctx context.Context, steps int) error {
+	return db.withMigrationLock(ctx, func(ctx context.Context, conn *sql.Conn, current int) error {
+		return db.migrate(ctx, conn, current, max(current-steps, 0))
+	})
+}
+
+// MigrateTo applies or reverts migrations until the schema has the given
+// version. Concurrent calls, also from other processes, are serialised.
+func (db Database) MigrateTo(ctx context.Context, version int) error {
+	if version < 0 || version > LatestSchemaVersion() {
+		return fmt.Errorf("%w: %d", ErrUnknownSchemaVersion, version)
+	}
+	return db.withMigrationLock(ctx, func(ctx context.Context, conn *sql.Conn, current int) error {
+		return db.migrate(ctx, conn, current, version)
+	})
+}
+
+func (db Database) withMigrationLock(ctx context.Context, f func(ctx context.Context, conn *sql.Conn, current int) error) error {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Waiting for the lock and running the migrations may take longer than
+	// the statement timeout of the pool.
+	_, err = conn.ExecContext(ctx, "SET statement_timeout = 0")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "RESET statement_timeout")
+		if err != nil {
+			db.logger.Error("failed to reset statement timeout", "error", err.Error())
+		}
+	}()
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID)
+		if err != nil {
+			db.logger.Error("failed to release migration lock", "error", err.Error())
+		}
+	}()
+	err = createMigrationsTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+	current, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("%w: database has version %d, latest known is %d", ErrUnknownSchemaVersion, current, LatestSchemaVersion())
+	}
+	return f(ctx, conn, current)
+}
+
+func createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	return err
+}
+
+func schemaVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var version int
+	err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+func (db Database) migrate(ctx context.Context, conn *sql.Conn, current int, target int) error {
+	for current < target {
+		m := migrations[current]
+		db.logger.Info("applying migration", "version", m.version, "name", m.name)
+		err := db.applyMigration(ctx, conn, m.up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.version, m.name)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+		}
+		current++
+	}
+	for current > target {
+		m := migrations[current-1]
+		db.logger.Info("reverting migration", "version", m.version, "name", m.name)
+		err := db.applyMigration(ctx, conn, m.down, "DELETE FROM schema_migrations WHERE version = $1", m.version)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+		}
+		current--
+	}
+	return nil
+}
+
+func (db Database) applyMigration(ctx context.Context, conn *sql.Conn, script string, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer db.rollback(tx)
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, record, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations_LoadsEmbeddedMigrations(t *testing.T) {
+	loaded, err := loadMigrations(migrationFiles)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, loaded)
+}
+
+func TestLoadMigrations_SortsMigrationsByVersion(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0002_second.up.sql":   {Data: []byte("up 2")},
+		"migrations/0002_second.down.sql": {Data: []byte("down 2")},
+		"migrations/0001_first.up.sql":    {Data: []byte("up 1")},
+		"migrations/0001_first.down.sql":  {Data: []byte("down 1")},
+	}
+
+	loaded, err := loadMigrations(files)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []migration{
+		{version: 1, name: "first", up: "up 1", down: "down 1"},
+		{version: 2, name: "second", up: "up 2", down: "down 2"},
+	}, loaded)
+}
+
+func TestLoadMigrations_ReturnsErrorIfInvalid(t *testing.T) {
+	data := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"MissingDown", fstest.MapFS{
+			"migrations/0001_first.up.sql": {Data: []byte("up 1")},
+		}},
+		{"MissingVersion", fstest.MapFS{
+			"migrations/0002_second.up.sql":   {Data: []byte("up 2")},
+			"migrations/0002_second.down.sql": {Data: []byte("down 2")},
+		}},
+		{"DifferentNames", fstest.MapFS{
+			"migrations/0001_first.up.sql":   {Data: []byte("up 1")},
+			"migrations/0001_other.down.sql": {Data: []byte("down 1")},
+		}},
+		{"UnexpectedName", fstest.MapFS{
+			"migrations/first.sql": {Data: []byte("up 1")},
+		}},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			_, err := loadMigrations(d.files)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestDatabase_MigrateDownRevertsMigrations(t *testing.T) {
+	db := openDatabase(t)
+	t.Cleanup(func() {
+		err := db.Migrate(context.Background())
+		assert.Nil(t, err)
+	})
+
+	err := db.MigrateDown(t.Context(), 1)
+	require.Nil(t, err)
+	version, err := db.SchemaVersion(t.Context())
+
+	assert.Nil(t, err)
+	assert.Equal(t, LatestSchemaVersion()-1, version)
+}
+
+func TestDatabase_MigrateAppliesPendingMigrations(t *testing.T) {
+	db := openDatabase(t)
+	err := db.MigrateTo(t.Context(), LatestSchemaVersion()-1)
+	require.Nil(t, err)
+
+	err = db.Migrate(t.Context())
+	require.Nil(t, err)
+	version, err := db.SchemaVersion(t.Context())
+
+	assert.Nil(t, err)
+	assert.Equal(t, LatestSchemaVersion(), version)
+}
+
+func TestDatabase_MigrateToReturnsErrUnknownSchemaVersion(t *testing.T) {
+	db := openDatabase(t)
+
+	err := db.MigrateTo(t.Context(), LatestSchemaVersion()+1)
+
+	assert.ErrorIs(t, err, ErrUnknownSchemaVersion)
+}
+
+func TestDatabase_MigrateWaitsForLockLongerThanStatementTimeout(t *testing.T) {
+	holder := openDatabase(t)
+	conn, err := holder.db.Conn(t.Context())
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = conn.ExecContext(t.Context(), "SELECT pg_advisory_lock($1)", migrationLockID)
+	require.Nil(t, err)
+	db, err := NewDatabase(t.Context(), Config{
+		DSN:              os.Getenv("DATABASE_URL"),
+		Logger:           createLogger(),
+		StatementTimeout: 50 * time.Millisecond,
+	})
+	require.Nil(t, err)
+	t.Cleanup(db.Close)
+	done := make(chan error)
+	go func() {
+		done <- db.Migrate(t.Context())
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	_, err = conn.ExecContext(t.Context(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+	require.Nil(t, err)
+
+	assert.Nil(t, <-done)
+	var timeout string
+	err = db.db.QueryRowContext(t.Context(), "SHOW statement_timeout").Scan(&timeout)
+	assert.Nil(t, err)
+	assert.Equal(t, "50ms", timeout)
+}
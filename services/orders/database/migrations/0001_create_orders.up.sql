@@ -1,4 +1,4 @@
 CREATE TABLE IF NOT EXISTS orders (
     id SERIAL PRIMARY KEY,
     title TEXT NOT NULL
-);
\ No newline at end of file
+);
@@ -0,0 +1,2 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'created'
+    CHECK (status IN ('created', 'confirmed', 'paid', 'shipped', 'delivered', 'cancelled'));
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS order_items;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS total_amount;
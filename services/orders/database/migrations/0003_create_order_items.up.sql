@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_amount BIGINT NOT NULL DEFAULT 0
+    CHECK (total_amount >= 0);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS order_items (
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    sku TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price_amount BIGINT NOT NULL CHECK (unit_price_amount >= 0),
+    currency CHAR(3) NOT NULL,
+    PRIMARY KEY (order_id, position)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    order_id INTEGER REFERENCES orders (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
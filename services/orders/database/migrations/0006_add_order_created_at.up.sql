@@ -0,0 +1,4 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at, id);
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status);
@@ -0,0 +1,20 @@
+DROP POLICY IF EXISTS idempotency_keys_tenant_isolation ON idempotency_keys;
+ALTER TABLE idempotency_keys DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_items_tenant_isolation ON order_items;
+ALTER TABLE order_items DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS orders_tenant_isolation ON orders;
+ALTER TABLE orders DISABLE ROW LEVEL SECURITY;
+
+DELETE FROM idempotency_keys a USING idempotency_keys b
+    WHERE a.key = b.key AND a.expires_at < b.expires_at;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key);
+ALTER TABLE idempotency_keys DROP COLUMN tenant_id;
+
+DROP INDEX IF EXISTS orders_tenant_status_idx;
+DROP INDEX IF EXISTS orders_tenant_created_at_idx;
+DROP INDEX IF EXISTS orders_tenant_id_idx;
+CREATE INDEX orders_created_at_idx ON orders (created_at, id);
+CREATE INDEX orders_status_idx ON orders (status);
+
+ALTER TABLE orders DROP COLUMN tenant_id;
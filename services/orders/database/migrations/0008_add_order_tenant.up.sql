@@ -0,0 +1,29 @@
+-- Orders created before tenants were introduced belong to the "default" tenant.
+ALTER TABLE orders ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE orders ALTER COLUMN tenant_id DROP DEFAULT;
+
+DROP INDEX IF EXISTS orders_created_at_idx;
+DROP INDEX IF EXISTS orders_status_idx;
+CREATE INDEX orders_tenant_id_idx ON orders (tenant_id, id);
+CREATE INDEX orders_tenant_created_at_idx ON orders (tenant_id, created_at, id);
+CREATE INDEX orders_tenant_status_idx ON orders (tenant_id, status);
+
+ALTER TABLE idempotency_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE idempotency_keys ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, key);
+
+-- The service sets app.tenant_id in every transaction. The policies are not
+-- applied to the owner of the tables, so to enforce them either run the
+-- service as a different role or FORCE ROW LEVEL SECURITY on the tables.
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+CREATE POLICY orders_tenant_isolation ON orders
+    USING (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE order_items ENABLE ROW LEVEL SECURITY;
+CREATE POLICY order_items_tenant_isolation ON order_items
+    USING (EXISTS (SELECT 1 FROM orders WHERE orders.id = order_items.order_id));
+
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+CREATE POLICY idempotency_keys_tenant_isolation ON idempotency_keys
+    USING (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_tenant_customer_created_at_idx;
+
+ALTER TABLE orders DROP COLUMN customer_id;
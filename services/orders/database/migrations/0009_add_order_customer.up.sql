@@ -0,0 +1,6 @@
+-- Orders created before ownership was introduced have no customer and are
+-- only visible to admins.
+ALTER TABLE orders ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ALTER COLUMN customer_id DROP DEFAULT;
+
+CREATE INDEX orders_tenant_customer_created_at_idx ON orders (tenant_id, customer_id, created_at, id);
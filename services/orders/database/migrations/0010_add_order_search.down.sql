@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_search_vector_idx;
+
+ALTER TABLE orders DROP COLUMN search_vector;
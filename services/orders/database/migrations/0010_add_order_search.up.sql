@@ -0,0 +1,6 @@
+-- Title words weigh most. Further searchable fields are appended to the
+-- expression with lower weights.
+ALTER TABLE orders ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('english', title), 'A')) STORED;
+
+CREATE INDEX orders_search_vector_idx ON orders USING GIN (search_vector);
@@ -0,0 +1,10 @@
+-- Events published to LISTEN/NOTIFY subscribers. Notifications only carry
+-- the id, so listeners read the event from here and catch up after a gap.
+CREATE TABLE IF NOT EXISTS order_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS order_events_created_at_idx ON order_events (created_at);
@@ -0,0 +1,2 @@
+DROP POLICY IF EXISTS idempotency_keys_expired_delete ON idempotency_keys;
+DROP POLICY IF EXISTS idempotency_keys_expired_select ON idempotency_keys;
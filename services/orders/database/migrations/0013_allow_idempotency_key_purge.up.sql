@@ -0,0 +1,6 @@
+-- Expired keys are deleted outside of a tenant, so they are visible and
+-- deletable whatever app.tenant_id is.
+CREATE POLICY idempotency_keys_expired_select ON idempotency_keys FOR SELECT
+    USING (expires_at <= now());
+CREATE POLICY idempotency_keys_expired_delete ON idempotency_keys FOR DELETE
+    USING (expires_at <= now());
@@ -0,0 +1,308 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mrstecklo/micropet/services/orders/events"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// eventsChannel is the channel notified with the id of every published
+// event.
+const eventsChannel = "order_events"
+
+// NotifyMessagingSystem is an orders.MessagingSystem that stores events in
+// the order_events table, in the JSON format of CloudEvents, and announces
+// them with pg_notify. Notifications are limited to 8000 bytes, so they only
+// carry the id of the event and a Listener reads the event itself.
+type NotifyMessagingSystem struct {
+	db Database
+}
+
+func NewNotifyMessagingSystem(db Database) NotifyMessagingSystem {
+	return NotifyMessagingSystem{db: db}
+}
+
+func (m NotifyMessagingSystem) PublishOrderCreated(ctx context.Context, order orders.Order) error {
+	return m.publish(ctx, []orders.Event{{Type: orders.EventOrderCreated, Order: order}})
+}
+
+func (m NotifyMessagingSystem) PublishOrdersCreated(ctx context.Context, created []orders.Order) error {
+	events := make([]orders.Event, 0, len(created))
+	for _, order := range created {
+		events = append(events, orders.Event{Type: orders.EventOrderCreated, Order: order})
+	}
+	return m.publish(ctx, events)
+}
+
+func (m NotifyMessagingSystem) PublishOrderStatusChanged(ctx context.Context, order orders.Order, previous orders.Status, changedAt time.Time) error {
+	return m.publish(ctx, []orders.Event{{
+		Type:           orders.EventOrderStatusChanged,
+		Order:          order,
+		PreviousStatus: previous,
+		CreatedAt:      changedAt,
+	}})
+}
+
+// publish stores the events and notifies the id of the last one. Publishers
+// take turns, so events are committed in the order of their ids and a
+// listener that has seen an id has seen every smaller one.
+func (m NotifyMessagingSystem) publish(ctx context.Context, published []orders.Event) error {
+	if len(published) == 0 {
+		return nil
+	}
+	tx, err := m.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer m.db.rollback(tx)
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", eventsChannel)
+	if err != nil {
+		return err
+	}
+	var id int64
+	for _, event := range published {
+		id, err = insertPublishedEvent(ctx, tx, event)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", eventsChannel, strconv.FormatInt(id, 10))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertPublishedEvent(ctx context.Context, tx *sql.Tx, event orders.Event) (int64, error) {
+	var cloudEvent events.CloudEvent
+	var err error
+	if event.Type == orders.EventOrderStatusChanged {
+		cloudEvent, err = events.NewOrderStatusChanged(event.Order, event.PreviousStatus, event.CreatedAt)
+	} else {
+		cloudEvent, err = events.NewOrderCreated(event.Order)
+	}
+	if err != nil {
+		return 0, err
+	}
+	payload, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = tx.QueryRowContext(ctx, "INSERT INTO order_events (event_type, payload) VALUES ($1, $2) RETURNING id",
+		event.Type, string(payload)).Scan(&id)
+	return id, err
+}
+
+// LatestEventID returns the id of the last published event, or zero if
+// there is none. A new Listener starts after it to skip the history.
+func (m NotifyMessagingSystem) LatestEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := m.db.db.QueryRowContext(ctx, "SELECT coalesce(max(id), 0) FROM order_events").Scan(&id)
+	return id, err
+}
+
+// PruneEvents deletes the events published before the time. Listeners that
+// have not seen them yet will not get them.
+func (m NotifyMessagingSystem) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := m.db.db.ExecContext(ctx, "DELETE FROM order_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// EventHandler handles an event received by a Listener. orders.Event.ID is
+// the id of the published event. The order of a status change only has the
+// fields of the event schema: its id, tenant, customer, status and version.
+type EventHandler func(ctx context.Context, event orders.Event) error
+
+// Listener receives the events of a NotifyMessagingSystem. It listens on a
+// dedicated connection outside of the pool, and after connecting reads the
+// events it missed from the order_events table, so events published while it
+// was disconnected are delivered late instead of lost.
+type Listener struct {
+	dsn        string
+	logger     *slog.Logger
+	after      int64
+	batchSize  int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Run calls handle for every event in the order of their ids until ctx is
+// cancelled. If handle fails, the connection is reset and the event is
+// delivered again after a backoff.
+func (l Listener) Run(ctx context.Context, handle EventHandler) {
+	l.logger.Info("starting event listener", "after", l.after)
+	backoff := l.minBackoff
+	for {
+		connected, err := l.listen(ctx, handle)
+		if ctx.Err() != nil {
+			l.logger.Info("event listener stopped", "after", l.after)
+			return
+		}
+		if connected {
+			backoff = l.minBackoff
+		}
+		l.logger.Error("event listener disconnected", "error", err.Error(), "retry_in", backoff.String())
+		select {
+		case <-ctx.Done():
+			l.logger.Info("event listener stopped", "after", l.after)
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, l.maxBackoff)
+	}
+}
+
+// listen connects and handles events until an error occurs. It reports
+// whether it got as far as listening, so Run knows to reset the backoff.
+func (l *Listener) listen(ctx context.Context, handle EventHandler) (bool, error) {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(ctx, "LISTEN "+eventsChannel)
+	if err != nil {
+		return false, err
+	}
+	// Events published from here on are notified, so catching up now leaves
+	// no gap.
+	for {
+		err = l.catchUp(ctx, conn, handle)
+		if err != nil {
+			return true, err
+		}
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		l.logger.Debug("received event notification", "id", notification.Payload)
+	}
+}
+
+// catchUp handles the events after the last handled one.
+func (l *Listener) catchUp(ctx context.Context, conn *pgx.Conn, handle EventHandler) error {
+	for {
+		rows, err := conn.Query(ctx,
+			"SELECT id, event_type, payload, created_at FROM order_events WHERE id > $1 ORDER BY id LIMIT $2", l.after, l.batchSize)
+		if err != nil {
+			return err
+		}
+		received, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (orders.Event, error) {
+			var id int64
+			var eventType orders.EventType
+			var data []byte
+			var createdAt time.Time
+			err := row.Scan(&id, &eventType, &data, &createdAt)
+			if err != nil {
+				return orders.Event{}, err
+			}
+			event, err := decodePublishedEvent(id, eventType, data)
+			if err != nil {
+				return orders.Event{}, fmt.Errorf("invalid payload of event %d: %w", id, err)
+			}
+			if event.CreatedAt.IsZero() {
+				event.CreatedAt = createdAt.UTC()
+			}
+			return event, nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, event := range received {
+			err = handle(ctx, event)
+			if err != nil {
+				return fmt.Errorf("failed to handle event %d: %w", event.ID, err)
+			}
+			l.after = event.ID
+		}
+		if len(received) < l.batchSize {
+			return nil
+		}
+	}
+}
+
+// decodePublishedEvent decodes the payload of a published event. Events
+// published before the CloudEvents envelope was introduced have the data of
+// version 1 of the event schemas, and no time.
+func decodePublishedEvent(id int64, eventType orders.EventType, data []byte) (orders.Event, error) {
+	legacyType := events.TypeOrderCreated
+	if eventType == orders.EventOrderStatusChanged {
+		legacyType = events.TypeOrderStatusChanged
+	}
+	cloudEvent, err := events.ParseJSONOrLegacy(data, legacyType)
+	if err != nil {
+		return orders.Event{}, err
+	}
+	switch cloudEvent.Type {
+	case events.TypeOrderCreated:
+		created, err := events.DecodeOrderCreated(cloudEvent)
+		if err != nil {
+			return orders.Event{}, err
+		}
+		return orders.Event{
+			ID:        id,
+			Type:      orders.EventOrderCreated,
+			Order:     created.Order(),
+			CreatedAt: cloudEvent.Time,
+		}, nil
+	case events.TypeOrderStatusChanged:
+		changed, err := events.DecodeOrderStatusChanged(cloudEvent)
+		if err != nil {
+			return orders.Event{}, err
+		}
+		return orders.Event{
+			ID:             id,
+			Type:           orders.EventOrderStatusChanged,
+			Order:          changed.Order(),
+			PreviousStatus: orders.Status(changed.PreviousStatus),
+			CreatedAt:      cloudEvent.Time,
+		}, nil
+	default:
+		return orders.Event{}, fmt.Errorf("unknown event type %q", cloudEvent.Type)
+	}
+}
+
+type ListenerConfig struct {
+	// DSN of the database, like Config.DSN.
+	DSN    string
+	Logger *slog.Logger
+	// After is the id of the last event already handled. Events with
+	// greater ids are delivered.
+	After      int64
+	BatchSize  int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func NewListener(config ListenerConfig) Listener {
+	listener := Listener{
+		dsn:        config.DSN,
+		logger:     config.Logger,
+		after:      config.After,
+		batchSize:  config.BatchSize,
+		minBackoff: config.MinBackoff,
+		maxBackoff: config.MaxBackoff,
+	}
+	if listener.batchSize <= 0 {
+		listener.batchSize = 100
+	}
+	if listener.minBackoff <= 0 {
+		listener.minBackoff = 100 * time.Millisecond
+	}
+	if listener.maxBackoff < listener.minBackoff {
+		listener.maxBackoff = max(30*time.Second, listener.minBackoff)
+	}
+	return listener
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/databasetest"
+	"github.com/mrstecklo/micropet/services/orders/events"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notifyFixture struct {
+	messaging NotifyMessagingSystem
+	after     int64
+}
+
+func setUpNotifyTest(t *testing.T) notifyFixture {
+	messaging := NewNotifyMessagingSystem(setUpDatabaseTest(t).db)
+	after, err := messaging.LatestEventID(t.Context())
+	require.Nil(t, err)
+	return notifyFixture{
+		messaging: messaging,
+		after:     after,
+	}
+}
+
+// startListener runs a listener until the test ends and returns the
+// channel of the events it handled.
+func startListener(t *testing.T, after int64, handle EventHandler) <-chan orders.Event {
+	events := make(chan orders.Event, 10)
+	listener := NewListener(ListenerConfig{
+		DSN:        os.Getenv("DATABASE_URL"),
+		Logger:     createLogger(),
+		After:      after,
+		MinBackoff: 10 * time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx, func(ctx context.Context, event orders.Event) error {
+			err := handle(ctx, event)
+			if err == nil {
+				events <- event
+			}
+			return err
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return events
+}
+
+func handleAll(ctx context.Context, event orders.Event) error {
+	return nil
+}
+
+func receive(t *testing.T, events <-chan orders.Event) orders.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return orders.Event{}
+	}
+}
+
+func TestListener_ReceivesPublishedEvents(t *testing.T) {
+	f := setUpNotifyTest(t)
+	events := startListener(t, f.after, handleAll)
+	order := databasetest.NewOrder("duck")
+	order.ID = 1
+
+	require.Nil(t, f.messaging.PublishOrdersCreated(t.Context(), []orders.Order{order, order}))
+	order.Status = orders.StatusPaid
+	changedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), order, orders.StatusCreated, changedAt))
+
+	first := receive(t, events)
+	assert.Equal(t, orders.EventOrderCreated, first.Type)
+	assert.Equal(t, "duck", first.Order.Title)
+	second := receive(t, events)
+	assert.Equal(t, first.ID+1, second.ID)
+	changed := receive(t, events)
+	assert.Equal(t, orders.EventOrderStatusChanged, changed.Type)
+	assert.Equal(t, orders.StatusPaid, changed.Order.Status)
+	assert.Equal(t, orders.StatusCreated, changed.PreviousStatus)
+	assert.Equal(t, changedAt, changed.CreatedAt)
+}
+
+func TestListener_UpcastsEventsPublishedBeforeCloudEvents(t *testing.T) {
+	f := setUpNotifyTest(t)
+	events := startListener(t, f.after, handleAll)
+	order := databasetest.NewOrder("duck")
+	order.ID = 1
+	payload, err := json.Marshal(newEventPayload(order, ""))
+	require.Nil(t, err)
+
+	_, err = f.messaging.db.db.ExecContext(t.Context(), "INSERT INTO order_events (event_type, payload) VALUES ($1, $2)",
+		orders.EventOrderCreated, string(payload))
+	require.Nil(t, err)
+
+	created := receive(t, events)
+	assert.Equal(t, orders.EventOrderCreated, created.Type)
+	assert.Equal(t, order, created.Order)
+	assert.False(t, created.CreatedAt.IsZero())
+}
+
+func TestNotifyMessagingSystem_StoresCloudEvents(t *testing.T) {
+	f := setUpNotifyTest(t)
+	order := databasetest.NewOrder("duck")
+	order.ID = 1
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), order))
+
+	var payload []byte
+	err := f.messaging.db.db.QueryRowContext(t.Context(), "SELECT payload FROM order_events WHERE id > $1", f.after).
+		Scan(&payload)
+	require.Nil(t, err)
+	event, err := events.ParseJSON(payload)
+	require.Nil(t, err)
+	assert.Equal(t, events.TypeOrderCreated, event.Type)
+	assert.Equal(t, events.DataSchema(events.TypeOrderCreated, 2), event.DataSchema)
+	assert.Equal(t, "acme:1:1:created", event.ID)
+}
+
+func TestDecodePublishedEvent_DecodesCloudEvent(t *testing.T) {
+	order := orders.Order{ID: 1, TenantID: "acme", CustomerID: "alice", Status: orders.StatusPaid, Version: 2}
+	changedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	event, err := events.NewOrderStatusChanged(order, orders.StatusCreated, changedAt)
+	require.Nil(t, err)
+	payload, err := json.Marshal(event)
+	require.Nil(t, err)
+
+	decoded, err := decodePublishedEvent(7, orders.EventOrderStatusChanged, payload)
+
+	assert.Nil(t, err)
+	assert.Equal(t, orders.Event{
+		ID:             7,
+		Type:           orders.EventOrderStatusChanged,
+		Order:          order,
+		PreviousStatus: orders.StatusCreated,
+		CreatedAt:      changedAt,
+	}, decoded)
+}
+
+func TestDecodePublishedEvent_UpcastsLegacyPayload(t *testing.T) {
+	order := databasetest.NewOrder("duck")
+	order.ID = 1
+	order.Status = orders.StatusPaid
+	order.Version = 2
+	payload, err := json.Marshal(newEventPayload(order, orders.StatusCreated))
+	require.Nil(t, err)
+
+	decoded, err := decodePublishedEvent(7, orders.EventOrderStatusChanged, payload)
+
+	assert.Nil(t, err)
+	assert.Equal(t, orders.Event{
+		ID:   7,
+		Type: orders.EventOrderStatusChanged,
+		Order: orders.Order{
+			ID:         1,
+			TenantID:   order.TenantID,
+			CustomerID: order.CustomerID,
+			Status:     orders.StatusPaid,
+			Version:    2,
+		},
+		PreviousStatus: orders.StatusCreated,
+	}, decoded)
+}
+
+func TestDecodePublishedEvent_RejectsMalformedPayload(t *testing.T) {
+	_, err := decodePublishedEvent(7, orders.EventOrderCreated, []byte("not json"))
+
+	assert.ErrorIs(t, err, events.ErrInvalidEvent)
+}
+
+func TestListener_CatchesUpOnEventsPublishedBeforeStart(t *testing.T) {
+	f := setUpNotifyTest(t)
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), databasetest.NewOrder("missed")))
+
+	events := startListener(t, f.after, handleAll)
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), databasetest.NewOrder("live")))
+
+	assert.Equal(t, "missed", receive(t, events).Order.Title)
+	assert.Equal(t, "live", receive(t, events).Order.Title)
+}
+
+func TestListener_RedeliversEventAfterHandlerError(t *testing.T) {
+	f := setUpNotifyTest(t)
+	failures := 2
+	events := startListener(t, f.after, func(ctx context.Context, event orders.Event) error {
+		if failures > 0 {
+			failures -= 1
+			return errors.New("try again")
+		}
+		return nil
+	})
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), databasetest.NewOrder("duck")))
+
+	assert.Equal(t, "duck", receive(t, events).Order.Title)
+}
+
+func TestNotifyMessagingSystem_PruneEventsDeletesOldEvents(t *testing.T) {
+	f := setUpNotifyTest(t)
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), databasetest.NewOrder("duck")))
+
+	pruned, err := f.messaging.PruneEvents(t.Context(), time.Now().Add(time.Minute))
+
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), pruned)
+}
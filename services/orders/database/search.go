@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+func (db Database) SearchOrders(ctx context.Context, tenant string, query orders.SearchQuery, page orders.Page) ([]orders.Order, error) {
+	tx, err := db.beginTx(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer db.rollback(tx)
+	var builder queryBuilder
+	text := builder.arg(query.Text)
+	builder.where("tenant_id = " + builder.arg(tenant))
+	builder.where("search_vector @@ search_query")
+	if query.CustomerID != "" {
+		builder.where("customer_id = " + builder.arg(query.CustomerID))
+	}
+	statement := "SELECT " + orderColumns + " FROM orders, websearch_to_tsquery('english', " + text + ") search_query" +
+		builder.whereClause() +
+		" ORDER BY ts_rank(search_vector, search_query) DESC, id DESC" +
+		" LIMIT " + builder.arg(page.Limit) + " OFFSET " + builder.arg(page.Offset)
+	found, err := queryOrders(ctx, tx, statement, builder.args...)
+	if err != nil {
+		return nil, err
+	}
+	return found, tx.Commit()
+}
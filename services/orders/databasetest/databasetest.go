@@ -0,0 +1,811 @@
+// Package databasetest holds the conformance suite every orders.Database
+// implementation has to pass.
+package databasetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend describes how the suite obtains databases under test.
+type Backend struct {
+	// Open returns an empty database and registers its cleanup on t.
+	Open func(t *testing.T) orders.Database
+	// Reopen closes db and returns a new handle to the same storage. Tests
+	// that data outlives a handle are skipped if Reopen is nil.
+	Reopen func(t *testing.T, db orders.Database) orders.Database
+}
+
+// Run runs the suite against databases opened by backend. The outbox tests
+// are skipped if the database does not implement orders.Outbox.
+func Run(t *testing.T, backend Backend) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, backend Backend)
+	}{
+		{"GetOrderReturnsErrNotFound", testGetOrderReturnsErrNotFound},
+		{"GetOrderReturnsErrNotFoundIfWrongId", testGetOrderReturnsErrNotFoundIfWrongId},
+		{"GetOrderReturnsCreatedOrder", testGetOrderReturnsCreatedOrder},
+		{"GetOrderReturnsRespectiveOrder", testGetOrderReturnsRespectiveOrder},
+		{"CreateOrderAssignsIncreasingIds", testCreateOrderAssignsIncreasingIds},
+		{"CreateOrdersStoresOrders", testCreateOrdersStoresOrders},
+		{"ImportOrdersStoresOrders", testImportOrdersStoresOrders},
+		{"StoresStateWhenReopened", testStoresStateWhenReopened},
+		{"UpdateOrderStatusPersistsStatus", testUpdateOrderStatusPersistsStatus},
+		{"UpdateOrderStatusReturnsTransitionErrorIfStatusChanged", testUpdateOrderStatusReturnsTransitionError},
+		{"UpdateOrderStatusReturnsErrVersionMismatch", testUpdateOrderStatusReturnsErrVersionMismatch},
+		{"UpdateOrderStatusReturnsErrNotFound", testUpdateOrderStatusReturnsErrNotFound},
+		{"UpdateOrderReplacesFields", testUpdateOrderReplacesFields},
+		{"UpdateOrderReturnsErrVersionMismatch", testUpdateOrderReturnsErrVersionMismatch},
+		{"UpdateOrderReturnsErrNotFound", testUpdateOrderReturnsErrNotFound},
+		{"CreateOrderWithKeyCreatesOrderOnce", testCreateOrderWithKeyCreatesOrderOnce},
+		{"CreateOrderWithKeyReturnsErrIdempotencyKeyReused", testCreateOrderWithKeyReturnsErrIdempotencyKeyReused},
+		{"CreateOrderWithKeyIgnoresExpiredKey", testCreateOrderWithKeyIgnoresExpiredKey},
+		{"ListOrdersSortsOrders", testListOrdersSortsOrders},
+		{"ListOrdersReturnsOrdersAfterCursor", testListOrdersReturnsOrdersAfterCursor},
+		{"ListOrdersFiltersOrders", testListOrdersFiltersOrders},
+		{"ListOrdersRespectsLimit", testListOrdersRespectsLimit},
+		{"ExportOrdersVisitsMatchingOrders", testExportOrdersVisitsMatchingOrders},
+		{"ExportOrdersStopsAtVisitError", testExportOrdersStopsAtVisitError},
+		{"SearchOrdersMatchesAllWords", testSearchOrdersMatchesAllWords},
+		{"SearchOrdersRanksBetterMatchesFirst", testSearchOrdersRanksBetterMatchesFirst},
+		{"SearchOrdersReturnsPage", testSearchOrdersReturnsPage},
+		{"SearchOrdersFiltersCustomer", testSearchOrdersFiltersCustomer},
+		{"GetOrderReturnsErrNotFoundForOtherTenant", testGetOrderReturnsErrNotFoundForOtherTenant},
+		{"UpdatesReturnErrNotFoundForOtherTenant", testUpdatesReturnErrNotFoundForOtherTenant},
+		{"ListOrdersReturnsOrdersOfTenant", testListOrdersReturnsOrdersOfTenant},
+		{"SearchOrdersReturnsOrdersOfTenant", testSearchOrdersReturnsOrdersOfTenant},
+		{"CreateOrderWithKeyScopesKeysByTenant", testCreateOrderWithKeyScopesKeysByTenant},
+		{"DeleteExpiredIdempotencyKeysKeepsUnexpiredKeys", testDeleteExpiredIdempotencyKeysKeepsUnexpiredKeys},
+		{"CreateOrderRecordsPendingEvent", testCreateOrderRecordsPendingEvent},
+		{"CreateOrdersRecordsPendingEvents", testCreateOrdersRecordsPendingEvents},
+		{"ImportOrdersRecordsPendingEvents", testImportOrdersRecordsPendingEvents},
+		{"UpdateOrderStatusRecordsPendingEvent", testUpdateOrderStatusRecordsPendingEvent},
+		{"MarkEventDeliveredRemovesPendingEvent", testMarkEventDeliveredRemovesPendingEvent},
+		{"PruneDeliveredEventsKeepsPendingEvents", testPruneDeliveredEventsKeepsPendingEvents},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, backend)
+		})
+	}
+}
+
+const (
+	tenant        = "acme"
+	otherTenant   = "umbrella"
+	customer      = "alice"
+	otherCustomer = "bob"
+)
+
+// NewOrder returns a new order as orders.Engine would pass it to CreateOrder.
+func NewOrder(title string) orders.Order {
+	return orders.Order{
+		TenantID:   tenant,
+		CustomerID: customer,
+		Title:      title,
+		Status:     orders.StatusCreated,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+		Version:    1,
+	}
+}
+
+func createOrder(t *testing.T, db orders.Database, order orders.Order) orders.Order {
+	id, err := db.CreateOrder(t.Context(), order)
+	require.Nil(t, err)
+	order.ID = id
+	return order
+}
+
+func openOutbox(t *testing.T, backend Backend) (orders.Database, orders.Outbox) {
+	db := backend.Open(t)
+	outbox, ok := db.(orders.Outbox)
+	if !ok {
+		t.Skip("database does not implement orders.Outbox")
+	}
+	return db, outbox
+}
+
+func testGetOrderReturnsErrNotFound(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+
+	_, err := db.GetOrder(t.Context(), tenant, 1)
+
+	assert.ErrorIs(t, err, orders.ErrNotFound)
+}
+
+func testGetOrderReturnsErrNotFoundIfWrongId(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrder(t, db, NewOrder("something"))
+
+	_, err := db.GetOrder(t.Context(), tenant, created.ID+1)
+
+	assert.ErrorIs(t, err, orders.ErrNotFound)
+}
+
+func testGetOrderReturnsCreatedOrder(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	expected := NewOrder("groceries")
+	expected.Items = []orders.Item{
+		{SKU: "milk", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}},
+		{SKU: "bread", Quantity: 1, UnitPrice: orders.Money{Amount: 320, Currency: "EUR"}},
+	}
+	expected.Total = orders.Money{Amount: 620, Currency: "EUR"}
+	expected = createOrder(t, db, expected)
+
+	order, err := db.GetOrder(t.Context(), tenant, expected.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, order)
+}
+
+func testGetOrderReturnsRespectiveOrder(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order1 := createOrder(t, db, NewOrder("something"))
+	order2 := createOrder(t, db, NewOrder("duck"))
+	order3 := createOrder(t, db, NewOrder("pickle"))
+
+	found2, err := db.GetOrder(t.Context(), tenant, order2.ID)
+	require.Nil(t, err)
+	found1, err := db.GetOrder(t.Context(), tenant, order1.ID)
+	require.Nil(t, err)
+	found3, err := db.GetOrder(t.Context(), tenant, order3.ID)
+	require.Nil(t, err)
+
+	assert.Equal(t, order1, found1)
+	assert.Equal(t, order2, found2)
+	assert.Equal(t, order3, found3)
+}
+
+func testCreateOrderAssignsIncreasingIds(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	previous := createOrder(t, db, NewOrder("something"))
+
+	for range 5 {
+		order := createOrder(t, db, NewOrder("something"))
+
+		assert.Greater(t, order.ID, previous.ID)
+		previous = order
+	}
+}
+
+func testCreateOrdersStoresOrders(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := []orders.Order{NewOrder("duck"), NewOrder("goose")}
+	created[1].Items = []orders.Item{{SKU: "egg", Quantity: 2, UnitPrice: orders.Money{Amount: 30, Currency: "EUR"}}}
+	created[1].Total = orders.Money{Amount: 60, Currency: "EUR"}
+
+	ids, err := db.CreateOrders(t.Context(), created)
+
+	require.Nil(t, err)
+	require.Len(t, ids, 2)
+	assert.Greater(t, ids[1], ids[0])
+	for idx, id := range ids {
+		created[idx].ID = id
+		order, err := db.GetOrder(t.Context(), tenant, id)
+		assert.Nil(t, err)
+		assert.Equal(t, created[idx], order)
+	}
+}
+
+func testImportOrdersStoresOrders(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	previous := createOrder(t, db, NewOrder("duck"))
+	imported := []orders.Order{NewOrder("goose"), NewOrder("egg")}
+	imported[0].CustomerID = otherCustomer
+	imported[1].Items = []orders.Item{
+		{SKU: "egg", Quantity: 2, UnitPrice: orders.Money{Amount: 30, Currency: "EUR"}},
+		{SKU: "nest", Quantity: 1, UnitPrice: orders.Money{Amount: 100, Currency: "EUR"}},
+	}
+	imported[1].Total = orders.Money{Amount: 160, Currency: "EUR"}
+
+	err := db.ImportOrders(t.Context(), imported)
+
+	require.Nil(t, err)
+	found, err := db.ListOrders(t.Context(), tenant, orders.ListQuery{Sort: orders.SortByIDAsc, After: &orders.Cursor{ID: previous.ID}, Limit: 10})
+	require.Nil(t, err)
+	require.Len(t, found, 2)
+	assert.Greater(t, found[1].ID, found[0].ID)
+	for idx := range imported {
+		imported[idx].ID = found[idx].ID
+	}
+	assert.Equal(t, imported, found)
+}
+
+func testStoresStateWhenReopened(t *testing.T, backend Backend) {
+	if backend.Reopen == nil {
+		t.Skip("database can not be reopened")
+	}
+	db := backend.Open(t)
+	created := createOrder(t, db, NewOrder("duck"))
+
+	db = backend.Reopen(t, db)
+	order, err := db.GetOrder(t.Context(), tenant, created.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, created, order)
+}
+
+func testUpdateOrderStatusPersistsStatus(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := createOrder(t, db, NewOrder("something"))
+	order.Status = orders.StatusConfirmed
+	order.Version = 2
+
+	err := db.UpdateOrderStatus(t.Context(), order, orders.StatusCreated, 1)
+	require.Nil(t, err)
+	stored, err := db.GetOrder(t.Context(), tenant, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, order, stored)
+}
+
+func testUpdateOrderStatusReturnsTransitionError(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := createOrder(t, db, NewOrder("something"))
+	order.Status = orders.StatusCancelled
+	order.Version = 2
+	err := db.UpdateOrderStatus(t.Context(), order, orders.StatusCreated, 1)
+	require.Nil(t, err)
+
+	order.Status = orders.StatusConfirmed
+	order.Version = 3
+	err = db.UpdateOrderStatus(t.Context(), order, orders.StatusCreated, 2)
+
+	assert.Equal(t, orders.TransitionError{From: orders.StatusCancelled, To: orders.StatusConfirmed}, err)
+}
+
+func testUpdateOrderStatusReturnsErrVersionMismatch(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := createOrder(t, db, NewOrder("something"))
+	updated := order
+	updated.Title = "duck"
+	updated.Version = 2
+	err := db.UpdateOrder(t.Context(), updated, 1)
+	require.Nil(t, err)
+
+	order.Status = orders.StatusConfirmed
+	order.Version = 2
+	err = db.UpdateOrderStatus(t.Context(), order, orders.StatusCreated, 1)
+	require.ErrorIs(t, err, orders.ErrVersionMismatch)
+	stored, err := db.GetOrder(t.Context(), tenant, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, updated, stored)
+}
+
+func testUpdateOrderStatusReturnsErrNotFound(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := NewOrder("something")
+	order.ID = 1
+	order.Status = orders.StatusConfirmed
+	order.Version = 2
+
+	err := db.UpdateOrderStatus(t.Context(), order, orders.StatusCreated, 1)
+
+	assert.ErrorIs(t, err, orders.ErrNotFound)
+}
+
+func testUpdateOrderReplacesFields(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := NewOrder("something")
+	order.Items = []orders.Item{{SKU: "milk", Quantity: 1, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}}
+	order.Total = orders.Money{Amount: 150, Currency: "EUR"}
+	updated := createOrder(t, db, order)
+	updated.Title = "duck"
+	updated.Items = []orders.Item{{SKU: "bread", Quantity: 2, UnitPrice: orders.Money{Amount: 320, Currency: "EUR"}}}
+	updated.Total = orders.Money{Amount: 640, Currency: "EUR"}
+	updated.Version = 2
+
+	err := db.UpdateOrder(t.Context(), updated, 1)
+	require.Nil(t, err)
+	stored, err := db.GetOrder(t.Context(), tenant, updated.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, updated, stored)
+}
+
+func testUpdateOrderReturnsErrVersionMismatch(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := createOrder(t, db, NewOrder("something"))
+	order.Version = 2
+	err := db.UpdateOrder(t.Context(), order, 1)
+	require.Nil(t, err)
+
+	order.Version = 3
+	err = db.UpdateOrder(t.Context(), order, 1)
+
+	assert.ErrorIs(t, err, orders.ErrVersionMismatch)
+}
+
+func testUpdateOrderReturnsErrNotFound(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	order := NewOrder("something")
+	order.ID = 1
+
+	err := db.UpdateOrder(t.Context(), order, 1)
+
+	assert.ErrorIs(t, err, orders.ErrNotFound)
+}
+
+func testCreateOrderWithKeyCreatesOrderOnce(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	key := orders.IdempotencyKey{Key: "key", Fingerprint: "abc", TTL: time.Hour}
+	id1, created1, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), key)
+	require.Nil(t, err)
+
+	id2, created2, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), key)
+
+	assert.Nil(t, err)
+	assert.True(t, created1)
+	assert.False(t, created2)
+	assert.Equal(t, id1, id2)
+}
+
+func testCreateOrderWithKeyReturnsErrIdempotencyKeyReused(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	_, _, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), orders.IdempotencyKey{Key: "key", Fingerprint: "abc", TTL: time.Hour})
+	require.Nil(t, err)
+
+	_, _, err = db.CreateOrderWithKey(t.Context(), NewOrder("duck"), orders.IdempotencyKey{Key: "key", Fingerprint: "def", TTL: time.Hour})
+
+	assert.ErrorIs(t, err, orders.ErrIdempotencyKeyReused)
+}
+
+func testCreateOrderWithKeyIgnoresExpiredKey(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	key := orders.IdempotencyKey{Key: "key", Fingerprint: "abc", TTL: time.Millisecond}
+	id1, _, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), key)
+	require.Nil(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	id2, created, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), key)
+
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, id1, id2)
+}
+
+func createOrdersForListing(t *testing.T, db orders.Database) []orders.Order {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		title    string
+		status   orders.Status
+		customer string
+	}{
+		{"yellow duck", orders.StatusCreated, customer},
+		{"green pickle", orders.StatusPaid, otherCustomer},
+		{"rubber duck", orders.StatusPaid, customer},
+		{"100% cotton", orders.StatusCancelled, otherCustomer},
+		{"duckling", orders.StatusCreated, customer},
+	}
+	var created []orders.Order
+	for idx, d := range data {
+		order := NewOrder(d.title)
+		order.Status = d.status
+		order.CustomerID = d.customer
+		order.CreatedAt = start.Add(time.Duration(idx) * time.Hour)
+		created = append(created, createOrder(t, db, order))
+	}
+	return created
+}
+
+func testListOrdersSortsOrders(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+	reversed := []orders.Order{created[4], created[3], created[2], created[1], created[0]}
+	data := []struct {
+		sort     orders.SortOrder
+		expected []orders.Order
+	}{
+		{orders.SortByIDAsc, created},
+		{orders.SortByIDDesc, reversed},
+		{orders.SortByCreatedAtAsc, created},
+		{orders.SortByCreatedAtDesc, reversed},
+	}
+	for _, d := range data {
+		t.Run(string(d.sort), func(t *testing.T) {
+			found, err := db.ListOrders(t.Context(), tenant, orders.ListQuery{Sort: d.sort, Limit: 10})
+
+			assert.Nil(t, err)
+			assert.Equal(t, d.expected, found)
+		})
+	}
+}
+
+func testListOrdersReturnsOrdersAfterCursor(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+	data := []struct {
+		sort     orders.SortOrder
+		after    orders.Order
+		expected []orders.Order
+	}{
+		{orders.SortByIDAsc, created[1], created[2:4]},
+		{orders.SortByIDDesc, created[3], []orders.Order{created[2], created[1]}},
+		{orders.SortByCreatedAtAsc, created[1], created[2:4]},
+		{orders.SortByCreatedAtDesc, created[3], []orders.Order{created[2], created[1]}},
+	}
+	for _, d := range data {
+		t.Run(string(d.sort), func(t *testing.T) {
+			found, err := db.ListOrders(t.Context(), tenant, orders.ListQuery{
+				Sort:  d.sort,
+				After: &orders.Cursor{Sort: d.sort, CreatedAt: d.after.CreatedAt, ID: d.after.ID},
+				Limit: 2,
+			})
+
+			assert.Nil(t, err)
+			assert.Equal(t, d.expected, found)
+		})
+	}
+}
+
+func testListOrdersFiltersOrders(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+	data := []struct {
+		name     string
+		filter   orders.ListFilter
+		expected []orders.Order
+	}{
+		{
+			"Status",
+			orders.ListFilter{Statuses: []orders.Status{orders.StatusPaid, orders.StatusCancelled}},
+			created[1:4],
+		},
+		{
+			"CreatedRange",
+			orders.ListFilter{CreatedFrom: created[1].CreatedAt, CreatedTo: created[3].CreatedAt},
+			created[1:3],
+		},
+		{
+			"TitleContains",
+			orders.ListFilter{TitleContains: "DUCK"},
+			[]orders.Order{created[0], created[2], created[4]},
+		},
+		{
+			"TitleContainsWildcard",
+			orders.ListFilter{TitleContains: "0%"},
+			[]orders.Order{created[3]},
+		},
+		{
+			"Customer",
+			orders.ListFilter{CustomerID: otherCustomer},
+			[]orders.Order{created[1], created[3]},
+		},
+		{
+			"Combined",
+			orders.ListFilter{Statuses: []orders.Status{orders.StatusCreated}, TitleContains: "duck"},
+			[]orders.Order{created[0], created[4]},
+		},
+		{
+			"NoMatch",
+			orders.ListFilter{TitleContains: "goose"},
+			nil,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			found, err := db.ListOrders(t.Context(), tenant, orders.ListQuery{Filter: d.filter, Sort: orders.SortByIDAsc, Limit: 10})
+
+			assert.Nil(t, err)
+			assert.Equal(t, d.expected, found)
+		})
+	}
+}
+
+func testListOrdersRespectsLimit(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+
+	found, err := db.ListOrders(t.Context(), tenant, orders.ListQuery{Sort: orders.SortByIDAsc, Limit: 3})
+
+	assert.Nil(t, err)
+	assert.Equal(t, created[:3], found)
+}
+
+func exportOrders(t *testing.T, db orders.Database, tenant string, filter orders.ListFilter) []orders.Order {
+	var exported []orders.Order
+	err := db.ExportOrders(t.Context(), tenant, filter, func(order orders.Order) error {
+		exported = append(exported, order)
+		return nil
+	})
+	require.Nil(t, err)
+	return exported
+}
+
+func testExportOrdersVisitsMatchingOrders(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+	other := NewOrder("duck")
+	other.TenantID = otherTenant
+	createOrder(t, db, other)
+
+	all := exportOrders(t, db, tenant, orders.ListFilter{})
+	paid := exportOrders(t, db, tenant, orders.ListFilter{
+		Statuses:    []orders.Status{orders.StatusPaid},
+		CreatedFrom: created[2].CreatedAt,
+	})
+
+	assert.Equal(t, created, all)
+	assert.Equal(t, []orders.Order{created[2]}, paid)
+}
+
+func testExportOrdersStopsAtVisitError(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	createOrdersForListing(t, db)
+	expectedError := errors.New("oh, no!")
+	visited := 0
+
+	err := db.ExportOrders(t.Context(), tenant, orders.ListFilter{}, func(order orders.Order) error {
+		visited += 1
+		return expectedError
+	})
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, 1, visited)
+}
+
+func testSearchOrdersMatchesAllWords(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+	data := []struct {
+		text     string
+		expected []orders.Order
+	}{
+		{"pickle", []orders.Order{created[1]}},
+		{"Rubber DUCK", []orders.Order{created[2]}},
+		{"duck cotton", nil},
+		{"goose", nil},
+	}
+	for _, d := range data {
+		t.Run(d.text, func(t *testing.T) {
+			found, err := db.SearchOrders(t.Context(), tenant, orders.SearchQuery{Text: d.text}, orders.Page{Limit: 10})
+
+			assert.Nil(t, err)
+			assert.Equal(t, d.expected, found)
+		})
+	}
+}
+
+func testSearchOrdersRanksBetterMatchesFirst(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	first := createOrder(t, db, NewOrder("yellow duck"))
+	best := createOrder(t, db, NewOrder("duck, duck, duck"))
+	last := createOrder(t, db, NewOrder("rubber duck"))
+
+	found, err := db.SearchOrders(t.Context(), tenant, orders.SearchQuery{Text: "duck"}, orders.Page{Limit: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Order{best, last, first}, found)
+}
+
+func testSearchOrdersReturnsPage(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	var created []orders.Order
+	for range 5 {
+		created = append(created, createOrder(t, db, NewOrder("duck")))
+	}
+
+	found, err := db.SearchOrders(t.Context(), tenant, orders.SearchQuery{Text: "duck"}, orders.Page{Offset: 1, Limit: 2})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Order{created[3], created[2]}, found)
+}
+
+func testSearchOrdersFiltersCustomer(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrdersForListing(t, db)
+
+	found, err := db.SearchOrders(t.Context(), tenant, orders.SearchQuery{Text: "duck", CustomerID: customer}, orders.Page{Limit: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Order{created[2], created[0]}, found)
+}
+
+func testGetOrderReturnsErrNotFoundForOtherTenant(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrder(t, db, NewOrder("something"))
+
+	_, err := db.GetOrder(t.Context(), otherTenant, created.ID)
+
+	assert.ErrorIs(t, err, orders.ErrNotFound)
+}
+
+func testUpdatesReturnErrNotFoundForOtherTenant(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	created := createOrder(t, db, NewOrder("something"))
+	changed := created
+	changed.TenantID = otherTenant
+	changed.Status = orders.StatusConfirmed
+	changed.Title = "duck"
+	changed.Version = 2
+
+	statusErr := db.UpdateOrderStatus(t.Context(), changed, orders.StatusCreated, 1)
+	updateErr := db.UpdateOrder(t.Context(), changed, 1)
+	stored, err := db.GetOrder(t.Context(), tenant, created.ID)
+
+	assert.ErrorIs(t, statusErr, orders.ErrNotFound)
+	assert.ErrorIs(t, updateErr, orders.ErrNotFound)
+	assert.Nil(t, err)
+	assert.Equal(t, created, stored)
+}
+
+func testListOrdersReturnsOrdersOfTenant(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	own := createOrder(t, db, NewOrder("duck"))
+	other := NewOrder("duck")
+	other.TenantID = otherTenant
+	other = createOrder(t, db, other)
+
+	found, err := db.ListOrders(t.Context(), tenant, orders.ListQuery{Sort: orders.SortByIDAsc, Limit: 10})
+	require.Nil(t, err)
+	foundOther, err := db.ListOrders(t.Context(), otherTenant, orders.ListQuery{Sort: orders.SortByIDAsc, Limit: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Order{own}, found)
+	assert.Equal(t, []orders.Order{other}, foundOther)
+}
+
+func testSearchOrdersReturnsOrdersOfTenant(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	own := createOrder(t, db, NewOrder("duck"))
+	other := NewOrder("duck")
+	other.TenantID = otherTenant
+	other = createOrder(t, db, other)
+
+	found, err := db.SearchOrders(t.Context(), tenant, orders.SearchQuery{Text: "duck"}, orders.Page{Limit: 10})
+	require.Nil(t, err)
+	foundOther, err := db.SearchOrders(t.Context(), otherTenant, orders.SearchQuery{Text: "duck"}, orders.Page{Limit: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Order{own}, found)
+	assert.Equal(t, []orders.Order{other}, foundOther)
+}
+
+func testCreateOrderWithKeyScopesKeysByTenant(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	key := orders.IdempotencyKey{Key: "key", Fingerprint: "abc", TTL: time.Hour}
+	other := NewOrder("something")
+	other.TenantID = otherTenant
+	id1, _, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), key)
+	require.Nil(t, err)
+
+	id2, created, err := db.CreateOrderWithKey(t.Context(), other, key)
+
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, id1, id2)
+}
+
+func testDeleteExpiredIdempotencyKeysKeepsUnexpiredKeys(t *testing.T, backend Backend) {
+	db := backend.Open(t)
+	expired := orders.IdempotencyKey{Key: "expired", Fingerprint: "abc", TTL: time.Millisecond}
+	kept := orders.IdempotencyKey{Key: "kept", Fingerprint: "abc", TTL: time.Hour}
+	other := NewOrder("something")
+	other.TenantID = otherTenant
+	_, _, err := db.CreateOrderWithKey(t.Context(), NewOrder("something"), expired)
+	require.Nil(t, err)
+	_, _, err = db.CreateOrderWithKey(t.Context(), other, expired)
+	require.Nil(t, err)
+	id, _, err := db.CreateOrderWithKey(t.Context(), NewOrder("duck"), kept)
+	require.Nil(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	deleted, err := db.DeleteExpiredIdempotencyKeys(t.Context())
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), deleted)
+	keptID, created, err := db.CreateOrderWithKey(t.Context(), NewOrder("duck"), kept)
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, id, keptID)
+}
+
+func testCreateOrderRecordsPendingEvent(t *testing.T, backend Backend) {
+	db, outbox := openOutbox(t, backend)
+	order := createOrder(t, db, NewOrder("something"))
+
+	events, err := outbox.PendingEvents(t.Context(), 10)
+
+	assert.Nil(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, orders.EventOrderCreated, events[0].Type)
+	assert.Equal(t, order, events[0].Order)
+}
+
+func testCreateOrdersRecordsPendingEvents(t *testing.T, backend Backend) {
+	db, outbox := openOutbox(t, backend)
+	ids, err := db.CreateOrders(t.Context(), []orders.Order{NewOrder("duck"), NewOrder("goose")})
+	require.Nil(t, err)
+
+	events, err := outbox.PendingEvents(t.Context(), 10)
+
+	assert.Nil(t, err)
+	require.Len(t, events, 2)
+	for idx, event := range events {
+		assert.Equal(t, orders.EventOrderCreated, event.Type)
+		assert.Equal(t, ids[idx], event.Order.ID)
+	}
+}
+
+func testImportOrdersRecordsPendingEvents(t *testing.T, backend Backend) {
+	db, outbox := openOutbox(t, backend)
+	err := db.ImportOrders(t.Context(), []orders.Order{NewOrder("duck"), NewOrder("goose")})
+	require.Nil(t, err)
+
+	events, err := outbox.PendingEvents(t.Context(), 10)
+
+	assert.Nil(t, err)
+	require.Len(t, events, 2)
+	for idx, title := range []string{"duck", "goose"} {
+		assert.Equal(t, orders.EventOrderCreated, events[idx].Type)
+		assert.Equal(t, title, events[idx].Order.Title)
+	}
+}
+
+func testUpdateOrderStatusRecordsPendingEvent(t *testing.T, backend Backend) {
+	db, outbox := openOutbox(t, backend)
+	changed := createOrder(t, db, NewOrder("something"))
+	changed.Status = orders.StatusConfirmed
+	changed.Version = 2
+	err := db.UpdateOrderStatus(t.Context(), changed, orders.StatusCreated, 1)
+	require.Nil(t, err)
+
+	events, err := outbox.PendingEvents(t.Context(), 10)
+
+	assert.Nil(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, orders.EventOrderStatusChanged, events[1].Type)
+	assert.Equal(t, changed, events[1].Order)
+	assert.Equal(t, orders.StatusCreated, events[1].PreviousStatus)
+	assert.WithinDuration(t, time.Now(), events[1].CreatedAt, time.Minute)
+}
+
+func testMarkEventDeliveredRemovesPendingEvent(t *testing.T, backend Backend) {
+	db, outbox := openOutbox(t, backend)
+	createOrder(t, db, NewOrder("something"))
+	createOrder(t, db, NewOrder("duck"))
+	createOrder(t, db, NewOrder("pickle"))
+	events, err := outbox.PendingEvents(t.Context(), 10)
+	require.Nil(t, err)
+	require.Len(t, events, 3)
+
+	err = outbox.MarkEventDelivered(t.Context(), events[1].ID)
+	require.Nil(t, err)
+	pending, err := outbox.PendingEvents(t.Context(), 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, events[:1], pending)
+	pending, err = outbox.PendingEvents(t.Context(), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Event{events[0], events[2]}, pending)
+}
+
+func testPruneDeliveredEventsKeepsPendingEvents(t *testing.T, backend Backend) {
+	db, outbox := openOutbox(t, backend)
+	createOrder(t, db, NewOrder("something"))
+	createOrder(t, db, NewOrder("duck"))
+	createOrder(t, db, NewOrder("pickle"))
+	events, err := outbox.PendingEvents(t.Context(), 10)
+	require.Nil(t, err)
+	require.Len(t, events, 3)
+	err = outbox.MarkEventDelivered(t.Context(), events[1].ID)
+	require.Nil(t, err)
+
+	pruned, err := outbox.PruneDeliveredEvents(t.Context(), time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), pruned)
+	pruned, err = outbox.PruneDeliveredEvents(t.Context(), time.Now().Add(time.Hour))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), pruned)
+	pending, err := outbox.PendingEvents(t.Context(), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Event{events[0], events[2]}, pending)
+}
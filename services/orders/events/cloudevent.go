@@ -0,0 +1,177 @@
+// Package events defines the CloudEvents 1.0 envelope of order events and
+// the versioned schemas of their data.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	SpecVersion = "1.0"
+	// ContentTypeJSON is the content type of the data of order events.
+	ContentTypeJSON = "application/json"
+	// ContentTypeCloudEventsJSON is the content type of a whole event in
+	// structured mode.
+	ContentTypeCloudEventsJSON = "application/cloudevents+json"
+)
+
+var ErrInvalidEvent = errors.New("invalid cloud event")
+
+// CloudEvent is an event in the CloudEvents 1.0 format. Its JSON encoding
+// is the JSON event format. Only JSON data is supported.
+type CloudEvent struct {
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	SpecVersion     string    `json:"specversion"`
+	Type            string    `json:"type"`
+	DataContentType string    `json:"datacontenttype,omitempty"`
+	DataSchema      string    `json:"dataschema,omitempty"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time,omitzero"`
+	// TenantID is the tenantid extension attribute.
+	TenantID string          `json:"tenantid,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+func (e CloudEvent) Validate() error {
+	switch {
+	case e.ID == "":
+		return fmt.Errorf("%w: missing id", ErrInvalidEvent)
+	case e.Source == "":
+		return fmt.Errorf("%w: missing source", ErrInvalidEvent)
+	case e.SpecVersion != SpecVersion:
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEvent, e.SpecVersion)
+	case e.Type == "":
+		return fmt.Errorf("%w: missing type", ErrInvalidEvent)
+	case e.DataContentType != "" && !isJSON(e.DataContentType):
+		return fmt.Errorf("%w: unsupported datacontenttype %q", ErrInvalidEvent, e.DataContentType)
+	}
+	return nil
+}
+
+// isJSON tells whether the media type is application/json or one of its
+// +json variants.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json"))
+}
+
+// ParseJSON parses an event in the JSON event format.
+func ParseJSON(data []byte) (CloudEvent, error) {
+	var event CloudEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		return event, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	return event, event.Validate()
+}
+
+// Mode is the way an event is carried by an HTTP message.
+type Mode string
+
+const (
+	// ModeStructured puts the whole event in JSON into the body.
+	ModeStructured Mode = "structured"
+	// ModeBinary puts the data into the body and the other attributes into
+	// ce- headers.
+	ModeBinary Mode = "binary"
+)
+
+// binaryHeaders are the headers of the attributes in binary mode, except
+// time and datacontenttype which is carried by Content-Type.
+var binaryHeaders = []struct {
+	name      string
+	attribute func(event *CloudEvent) *string
+}{
+	{"ce-id", func(e *CloudEvent) *string { return &e.ID }},
+	{"ce-source", func(e *CloudEvent) *string { return &e.Source }},
+	{"ce-specversion", func(e *CloudEvent) *string { return &e.SpecVersion }},
+	{"ce-type", func(e *CloudEvent) *string { return &e.Type }},
+	{"ce-dataschema", func(e *CloudEvent) *string { return &e.DataSchema }},
+	{"ce-subject", func(e *CloudEvent) *string { return &e.Subject }},
+	{"ce-tenantid", func(e *CloudEvent) *string { return &e.TenantID }},
+}
+
+// WriteHTTP sets the headers of an HTTP message carrying the event in the
+// mode and returns its body.
+func WriteHTTP(header http.Header, event CloudEvent, mode Mode) ([]byte, error) {
+	err := event.Validate()
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case ModeStructured:
+		header.Set("Content-Type", ContentTypeCloudEventsJSON)
+		return json.Marshal(event)
+	case ModeBinary:
+		for _, h := range binaryHeaders {
+			value := *h.attribute(&event)
+			if value != "" {
+				header.Set(h.name, encodeHeaderValue(value))
+			}
+		}
+		if !event.Time.IsZero() {
+			header.Set("ce-time", event.Time.Format(time.RFC3339Nano))
+		}
+		if event.DataContentType != "" {
+			header.Set("Content-Type", event.DataContentType)
+		}
+		return event.Data, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q", mode)
+	}
+}
+
+// ReadHTTP reads an event from an HTTP message in either mode.
+func ReadHTTP(header http.Header, body []byte) (CloudEvent, error) {
+	contentType := header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == ContentTypeCloudEventsJSON {
+		return ParseJSON(body)
+	}
+	if header.Get("ce-specversion") == "" {
+		return CloudEvent{}, fmt.Errorf("%w: neither structured nor binary mode", ErrInvalidEvent)
+	}
+	var event CloudEvent
+	for _, h := range binaryHeaders {
+		value, err := url.PathUnescape(header.Get(h.name))
+		if err != nil {
+			return event, fmt.Errorf("%w: %s: %w", ErrInvalidEvent, h.name, err)
+		}
+		*h.attribute(&event) = value
+	}
+	if value := header.Get("ce-time"); value != "" {
+		var err error
+		event.Time, err = time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return event, fmt.Errorf("%w: ce-time: %w", ErrInvalidEvent, err)
+		}
+	}
+	event.DataContentType = contentType
+	if len(body) > 0 {
+		event.Data = json.RawMessage(body)
+	}
+	return event, event.Validate()
+}
+
+// encodeHeaderValue percent-encodes the characters that must not appear in
+// a ce- header: space, double quote, percent and everything outside of
+// printable ASCII.
+func encodeHeaderValue(value string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		if b <= ' ' || b > '~' || b == '"' || b == '%' {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		} else {
+			encoded.WriteByte(b)
+		}
+	}
+	return encoded.String()
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvent = CloudEvent{
+	ID:              "acme:1:1:created",
+	Source:          Source,
+	SpecVersion:     SpecVersion,
+	Type:            TypeOrderCreated,
+	DataContentType: ContentTypeJSON,
+	DataSchema:      DataSchema(TypeOrderCreated, 2),
+	Subject:         "1",
+	Time:            time.Date(2026, 1, 2, 3, 4, 5, 500_000_000, time.UTC),
+	TenantID:        "acme",
+	Data:            json.RawMessage(`{"order_id":1}`),
+}
+
+func TestCloudEvent_EncodesJSONEventFormat(t *testing.T) {
+	encoded, err := json.Marshal(testEvent)
+	require.Nil(t, err)
+
+	assert.JSONEq(t, `{
+		"id": "acme:1:1:created",
+		"source": "/micropet/orders",
+		"specversion": "1.0",
+		"type": "com.micropet.orders.order.created",
+		"datacontenttype": "application/json",
+		"dataschema": "urn:micropet:schema:com.micropet.orders.order.created:v2",
+		"subject": "1",
+		"time": "2026-01-02T03:04:05.5Z",
+		"tenantid": "acme",
+		"data": {"order_id": 1}
+	}`, string(encoded))
+	parsed, err := ParseJSON(encoded)
+	require.Nil(t, err)
+	assert.Equal(t, testEvent, parsed)
+}
+
+func TestCloudEvent_OmitsUnsetOptionalAttributes(t *testing.T) {
+	encoded, err := json.Marshal(CloudEvent{ID: "1", Source: Source, SpecVersion: SpecVersion, Type: TypeOrderCreated})
+	require.Nil(t, err)
+
+	assert.JSONEq(t, `{"id": "1", "source": "/micropet/orders", "specversion": "1.0", "type": "com.micropet.orders.order.created"}`,
+		string(encoded))
+}
+
+func TestParseJSON_RejectsInvalidEvents(t *testing.T) {
+	data := []struct {
+		name  string
+		input string
+	}{
+		{"Malformed", `{"id": `},
+		{"MissingID", `{"source": "/s", "specversion": "1.0", "type": "t"}`},
+		{"MissingSource", `{"id": "1", "specversion": "1.0", "type": "t"}`},
+		{"UnsupportedSpecVersion", `{"id": "1", "source": "/s", "specversion": "0.3", "type": "t"}`},
+		{"MissingType", `{"id": "1", "source": "/s", "specversion": "1.0"}`},
+		{"BinaryData", `{"id": "1", "source": "/s", "specversion": "1.0", "type": "t", "datacontenttype": "image/png"}`},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			_, err := ParseJSON([]byte(d.input))
+
+			assert.True(t, errors.Is(err, ErrInvalidEvent), err)
+		})
+	}
+}
+
+func TestWriteHTTP_WritesStructuredMode(t *testing.T) {
+	header := http.Header{}
+
+	body, err := WriteHTTP(header, testEvent, ModeStructured)
+
+	require.Nil(t, err)
+	assert.Equal(t, ContentTypeCloudEventsJSON, header.Get("Content-Type"))
+	assert.Len(t, header, 1)
+	parsed, err := ParseJSON(body)
+	require.Nil(t, err)
+	assert.Equal(t, testEvent, parsed)
+}
+
+func TestWriteHTTP_WritesBinaryMode(t *testing.T) {
+	header := http.Header{}
+	event := testEvent
+	event.Subject = `duck "rubber" 100%`
+
+	body, err := WriteHTTP(header, event, ModeBinary)
+
+	require.Nil(t, err)
+	assert.Equal(t, http.Header{
+		"Ce-Id":          {"acme:1:1:created"},
+		"Ce-Source":      {"/micropet/orders"},
+		"Ce-Specversion": {"1.0"},
+		"Ce-Type":        {"com.micropet.orders.order.created"},
+		"Ce-Dataschema":  {"urn:micropet:schema:com.micropet.orders.order.created:v2"},
+		"Ce-Subject":     {"duck%20%22rubber%22%20100%25"},
+		"Ce-Time":        {"2026-01-02T03:04:05.5Z"},
+		"Ce-Tenantid":    {"acme"},
+		"Content-Type":   {"application/json"},
+	}, header)
+	assert.Equal(t, `{"order_id":1}`, string(body))
+}
+
+func TestWriteHTTP_RejectsInvalidEvent(t *testing.T) {
+	_, err := WriteHTTP(http.Header{}, CloudEvent{}, ModeBinary)
+
+	assert.True(t, errors.Is(err, ErrInvalidEvent), err)
+}
+
+func TestReadHTTP_ReadsEitherMode(t *testing.T) {
+	event := testEvent
+	event.Subject = "ünïcode"
+	for _, mode := range []Mode{ModeStructured, ModeBinary} {
+		t.Run(string(mode), func(t *testing.T) {
+			header := http.Header{}
+			body, err := WriteHTTP(header, event, mode)
+			require.Nil(t, err)
+
+			read, err := ReadHTTP(header, body)
+
+			require.Nil(t, err)
+			assert.Equal(t, event, read)
+		})
+	}
+}
+
+func TestReadHTTP_RejectsMessageWithoutEvent(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", ContentTypeJSON)
+
+	_, err := ReadHTTP(header, []byte(`{"order_id":1}`))
+
+	assert.True(t, errors.Is(err, ErrInvalidEvent), err)
+}
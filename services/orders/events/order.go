@@ -0,0 +1,257 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// Source is the source attribute of the events of the orders service.
+const Source = "/micropet/orders"
+
+const (
+	TypeOrderCreated       = "com.micropet.orders.order.created"
+	TypeOrderStatusChanged = "com.micropet.orders.order.status_changed"
+)
+
+// latestVersions are the schema versions of the data of new events.
+var latestVersions = map[string]int{
+	TypeOrderCreated:       2,
+	TypeOrderStatusChanged: 2,
+}
+
+// DataSchema returns the dataschema attribute of the version of the data of
+// the event type.
+func DataSchema(eventType string, version int) string {
+	return fmt.Sprintf("urn:micropet:schema:%s:v%d", eventType, version)
+}
+
+// SchemaVersion returns the version of the data of the event. Events
+// without dataschema were published before the schemas were versioned and
+// have version 1.
+func SchemaVersion(event CloudEvent) (int, error) {
+	if event.DataSchema == "" {
+		return 1, nil
+	}
+	for version := latestVersions[event.Type]; version > 0; version-- {
+		if event.DataSchema == DataSchema(event.Type, version) {
+			return version, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown dataschema %q of %s", ErrInvalidEvent, event.DataSchema, event.Type)
+}
+
+// MoneyV1 and the other V1 types are the data of both event types before
+// the schemas were versioned: the whole order, and for status changes the
+// previous status.
+type MoneyV1 struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type ItemV1 struct {
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice MoneyV1 `json:"unit_price"`
+}
+
+type OrderV1 struct {
+	ID         int       `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	CustomerID string    `json:"customer_id"`
+	Title      string    `json:"title"`
+	Status     string    `json:"status"`
+	Items      []ItemV1  `json:"items"`
+	Total      MoneyV1   `json:"total"`
+	CreatedAt  time.Time `json:"created_at"`
+	Version    int       `json:"version"`
+}
+
+type OrderEventV1 struct {
+	Order          OrderV1 `json:"order"`
+	PreviousStatus string  `json:"previous_status,omitempty"`
+}
+
+type MoneyV2 struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type ItemV2 struct {
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice MoneyV2 `json:"unit_price"`
+}
+
+type OrderCreatedV2 struct {
+	OrderID      int       `json:"order_id"`
+	TenantID     string    `json:"tenant_id"`
+	CustomerID   string    `json:"customer_id"`
+	Title        string    `json:"title"`
+	Status       string    `json:"status"`
+	Items        []ItemV2  `json:"items"`
+	Total        MoneyV2   `json:"total"`
+	CreatedAt    time.Time `json:"created_at"`
+	OrderVersion int       `json:"order_version"`
+}
+
+// OrderStatusChangedV2 only identifies the order, consumers that need more
+// of it get it from the orders service.
+type OrderStatusChangedV2 struct {
+	OrderID        int    `json:"order_id"`
+	TenantID       string `json:"tenant_id"`
+	CustomerID     string `json:"customer_id"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status"`
+	OrderVersion   int    `json:"order_version"`
+}
+
+// Order returns the order described by the data.
+func (d OrderCreatedV2) Order() orders.Order {
+	order := orders.Order{
+		ID:         d.OrderID,
+		TenantID:   d.TenantID,
+		CustomerID: d.CustomerID,
+		Title:      d.Title,
+		Status:     orders.Status(d.Status),
+		Total:      orders.Money(d.Total),
+		CreatedAt:  d.CreatedAt,
+		Version:    d.OrderVersion,
+	}
+	for _, item := range d.Items {
+		order.Items = append(order.Items, orders.Item{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: orders.Money(item.UnitPrice),
+		})
+	}
+	return order
+}
+
+// Order returns the part of the order described by the data.
+func (d OrderStatusChangedV2) Order() orders.Order {
+	return orders.Order{
+		ID:         d.OrderID,
+		TenantID:   d.TenantID,
+		CustomerID: d.CustomerID,
+		Status:     orders.Status(d.Status),
+		Version:    d.OrderVersion,
+	}
+}
+
+// eventID identifies the event of the type for the version of the order,
+// so it is the same when the event is published again.
+func eventID(eventType string, order orders.Order) string {
+	kind := eventType[strings.LastIndex(eventType, ".")+1:]
+	return fmt.Sprintf("%s:%d:%d:%s", order.TenantID, order.ID, order.Version, kind)
+}
+
+func newEvent(eventType string, order orders.Order, at time.Time, data any) (CloudEvent, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	return CloudEvent{
+		ID:              eventID(eventType, order),
+		Source:          Source,
+		SpecVersion:     SpecVersion,
+		Type:            eventType,
+		DataContentType: ContentTypeJSON,
+		DataSchema:      DataSchema(eventType, latestVersions[eventType]),
+		Subject:         strconv.Itoa(order.ID),
+		Time:            at.UTC(),
+		TenantID:        order.TenantID,
+		Data:            encoded,
+	}, nil
+}
+
+func NewOrderCreated(order orders.Order) (CloudEvent, error) {
+	data := OrderCreatedV2{
+		OrderID:      order.ID,
+		TenantID:     order.TenantID,
+		CustomerID:   order.CustomerID,
+		Title:        order.Title,
+		Status:       string(order.Status),
+		Items:        []ItemV2{},
+		Total:        MoneyV2(order.Total),
+		CreatedAt:    order.CreatedAt,
+		OrderVersion: order.Version,
+	}
+	for _, item := range order.Items {
+		data.Items = append(data.Items, ItemV2{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: MoneyV2(item.UnitPrice),
+		})
+	}
+	return newEvent(TypeOrderCreated, order, order.CreatedAt, data)
+}
+
+// NewOrderStatusChanged returns the event of the status change at the time.
+func NewOrderStatusChanged(order orders.Order, previous orders.Status, at time.Time) (CloudEvent, error) {
+	return newEvent(TypeOrderStatusChanged, order, at, OrderStatusChangedV2{
+		OrderID:        order.ID,
+		TenantID:       order.TenantID,
+		CustomerID:     order.CustomerID,
+		Status:         string(order.Status),
+		PreviousStatus: string(previous),
+		OrderVersion:   order.Version,
+	})
+}
+
+// ParseJSONOrLegacy parses an event in the JSON event format. Order events
+// published before the CloudEvents envelope was introduced are only the data
+// of version 1 of their schema, the type of the event is known from where it
+// was published.
+func ParseJSONOrLegacy(data []byte, legacyType string) (CloudEvent, error) {
+	var envelope struct {
+		SpecVersion string `json:"specversion"`
+	}
+	err := json.Unmarshal(data, &envelope)
+	if err != nil {
+		return CloudEvent{}, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	if envelope.SpecVersion != "" {
+		return ParseJSON(data)
+	}
+	return CloudEvent{
+		Source:      Source,
+		SpecVersion: SpecVersion,
+		Type:        legacyType,
+		Data:        data,
+	}, nil
+}
+
+// DecodeOrderCreated returns the data of the event in the latest schema.
+func DecodeOrderCreated(event CloudEvent) (OrderCreatedV2, error) {
+	var data OrderCreatedV2
+	err := decode(event, TypeOrderCreated, &data)
+	return data, err
+}
+
+// DecodeOrderStatusChanged returns the data of the event in the latest
+// schema.
+func DecodeOrderStatusChanged(event CloudEvent) (OrderStatusChangedV2, error) {
+	var data OrderStatusChangedV2
+	err := decode(event, TypeOrderStatusChanged, &data)
+	return data, err
+}
+
+func decode(event CloudEvent, eventType string, data any) error {
+	if event.Type != eventType {
+		return fmt.Errorf("%w: expected type %s, got %s", ErrInvalidEvent, eventType, event.Type)
+	}
+	event, err := Upcast(event)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(event.Data, data)
+	if err != nil {
+		return fmt.Errorf("%w: data of %s: %w", ErrInvalidEvent, event.ID, err)
+	}
+	return nil
+}
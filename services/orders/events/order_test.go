@@ -0,0 +1,190 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testOrder = orders.Order{
+	ID:         1,
+	TenantID:   "acme",
+	CustomerID: "alice",
+	Title:      "duck",
+	Status:     orders.StatusCreated,
+	Items:      []orders.Item{{SKU: "duck", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}},
+	Total:      orders.Money{Amount: 300, Currency: "EUR"},
+	CreatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Version:    1,
+}
+
+// legacyData is the data of the order events published before the schemas
+// were versioned.
+const legacyData = `{
+	"order": {
+		"id": 1,
+		"tenant_id": "acme",
+		"customer_id": "alice",
+		"title": "duck",
+		"status": "paid",
+		"items": [{"sku": "duck", "quantity": 2, "unit_price": {"amount": 150, "currency": "EUR"}}],
+		"total": {"amount": 300, "currency": "EUR"},
+		"created_at": "2026-01-02T03:04:05Z",
+		"version": 2
+	},
+	"previous_status": "created"
+}`
+
+func legacyEvent(eventType string) CloudEvent {
+	return CloudEvent{
+		ID:          "legacy",
+		Source:      Source,
+		SpecVersion: SpecVersion,
+		Type:        eventType,
+		Data:        json.RawMessage(legacyData),
+	}
+}
+
+func TestNewOrderCreated_ReturnsEventOfLatestSchema(t *testing.T) {
+	event, err := NewOrderCreated(testOrder)
+
+	require.Nil(t, err)
+	assert.Equal(t, "acme:1:1:created", event.ID)
+	assert.Equal(t, TypeOrderCreated, event.Type)
+	assert.Equal(t, DataSchema(TypeOrderCreated, 2), event.DataSchema)
+	assert.Equal(t, "1", event.Subject)
+	assert.Equal(t, "acme", event.TenantID)
+	assert.Equal(t, testOrder.CreatedAt, event.Time)
+	assert.JSONEq(t, `{
+		"order_id": 1,
+		"tenant_id": "acme",
+		"customer_id": "alice",
+		"title": "duck",
+		"status": "created",
+		"items": [{"sku": "duck", "quantity": 2, "unit_price": {"amount": 150, "currency": "EUR"}}],
+		"total": {"amount": 300, "currency": "EUR"},
+		"created_at": "2026-01-02T03:04:05Z",
+		"order_version": 1
+	}`, string(event.Data))
+	created, err := DecodeOrderCreated(event)
+	require.Nil(t, err)
+	assert.Equal(t, testOrder, created.Order())
+}
+
+func TestNewOrderStatusChanged_ReturnsEventOfLatestSchema(t *testing.T) {
+	order := testOrder
+	order.Status = orders.StatusPaid
+	order.Version = 2
+	at := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	event, err := NewOrderStatusChanged(order, orders.StatusCreated, at)
+
+	require.Nil(t, err)
+	assert.Equal(t, "acme:1:2:status_changed", event.ID)
+	assert.Equal(t, at, event.Time)
+	assert.JSONEq(t, `{
+		"order_id": 1,
+		"tenant_id": "acme",
+		"customer_id": "alice",
+		"status": "paid",
+		"previous_status": "created",
+		"order_version": 2
+	}`, string(event.Data))
+}
+
+func TestDecodeOrderCreated_UpcastsLegacyData(t *testing.T) {
+	created, err := DecodeOrderCreated(legacyEvent(TypeOrderCreated))
+
+	require.Nil(t, err)
+	order := testOrder
+	order.Status = orders.StatusPaid
+	order.Version = 2
+	assert.Equal(t, order, created.Order())
+}
+
+func TestDecodeOrderStatusChanged_UpcastsLegacyData(t *testing.T) {
+	changed, err := DecodeOrderStatusChanged(legacyEvent(TypeOrderStatusChanged))
+
+	require.Nil(t, err)
+	assert.Equal(t, OrderStatusChangedV2{
+		OrderID:        1,
+		TenantID:       "acme",
+		CustomerID:     "alice",
+		Status:         "paid",
+		PreviousStatus: "created",
+		OrderVersion:   2,
+	}, changed)
+}
+
+func TestDecodeOrderCreated_RejectsOtherType(t *testing.T) {
+	_, err := DecodeOrderCreated(legacyEvent(TypeOrderStatusChanged))
+
+	assert.True(t, errors.Is(err, ErrInvalidEvent), err)
+}
+
+func TestUpcast_SetsLatestDataSchema(t *testing.T) {
+	event, err := Upcast(legacyEvent(TypeOrderCreated))
+
+	require.Nil(t, err)
+	assert.Equal(t, DataSchema(TypeOrderCreated, 2), event.DataSchema)
+	version, err := SchemaVersion(event)
+	require.Nil(t, err)
+	assert.Equal(t, 2, version)
+}
+
+func TestUpcast_KeepsDataOfLatestSchema(t *testing.T) {
+	event, err := NewOrderCreated(testOrder)
+	require.Nil(t, err)
+
+	upcast, err := Upcast(event)
+
+	require.Nil(t, err)
+	assert.Equal(t, event, upcast)
+}
+
+func TestUpcast_RejectsUnknownSchemas(t *testing.T) {
+	data := []struct {
+		name  string
+		event CloudEvent
+	}{
+		{"UnknownType", CloudEvent{Type: "com.micropet.orders.order.deleted"}},
+		{"NewerVersion", CloudEvent{Type: TypeOrderCreated, DataSchema: DataSchema(TypeOrderCreated, 3)}},
+		{"OtherSchema", CloudEvent{Type: TypeOrderCreated, DataSchema: "https://example.com/order.json"}},
+		{"MalformedData", CloudEvent{Type: TypeOrderCreated, Data: json.RawMessage(`[]`)}},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			_, err := Upcast(d.event)
+
+			assert.True(t, errors.Is(err, ErrInvalidEvent), err)
+		})
+	}
+}
+
+func TestParseJSONOrLegacy_ParsesEitherFormat(t *testing.T) {
+	event, err := NewOrderCreated(testOrder)
+	require.Nil(t, err)
+	encoded, err := json.Marshal(event)
+	require.Nil(t, err)
+
+	parsed, err := ParseJSONOrLegacy(encoded, TypeOrderStatusChanged)
+	require.Nil(t, err)
+	assert.Equal(t, event, parsed)
+	legacy, err := ParseJSONOrLegacy([]byte(legacyData), TypeOrderStatusChanged)
+
+	require.Nil(t, err)
+	assert.Equal(t, TypeOrderStatusChanged, legacy.Type)
+	assert.Empty(t, legacy.DataSchema)
+	assert.JSONEq(t, legacyData, string(legacy.Data))
+}
+
+func TestParseJSONOrLegacy_RejectsMalformedData(t *testing.T) {
+	_, err := ParseJSONOrLegacy([]byte("not json"), TypeOrderCreated)
+
+	assert.True(t, errors.Is(err, ErrInvalidEvent), err)
+}
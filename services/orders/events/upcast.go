@@ -0,0 +1,82 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type upcasterKey struct {
+	eventType string
+	version   int
+}
+
+// upcasters convert data of a schema version to the next version.
+var upcasters = map[upcasterKey]func(data []byte) ([]byte, error){
+	{TypeOrderCreated, 1}:       upcastJSON(orderCreatedV1ToV2),
+	{TypeOrderStatusChanged, 1}: upcastJSON(orderStatusChangedV1ToV2),
+}
+
+// Upcast converts the data of the event to the latest schema version of its
+// type, so consumers only handle that one.
+func Upcast(event CloudEvent) (CloudEvent, error) {
+	latest, ok := latestVersions[event.Type]
+	if !ok {
+		return event, fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, event.Type)
+	}
+	version, err := SchemaVersion(event)
+	if err != nil {
+		return event, err
+	}
+	for ; version < latest; version++ {
+		event.Data, err = upcasters[upcasterKey{event.Type, version}](event.Data)
+		if err != nil {
+			return event, fmt.Errorf("%w: data of %s version %d: %w", ErrInvalidEvent, event.ID, version, err)
+		}
+	}
+	event.DataSchema = DataSchema(event.Type, latest)
+	return event, nil
+}
+
+func upcastJSON[From, To any](upcast func(From) To) func(data []byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		var from From
+		err := json.Unmarshal(data, &from)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(upcast(from))
+	}
+}
+
+func orderCreatedV1ToV2(v1 OrderEventV1) OrderCreatedV2 {
+	v2 := OrderCreatedV2{
+		OrderID:      v1.Order.ID,
+		TenantID:     v1.Order.TenantID,
+		CustomerID:   v1.Order.CustomerID,
+		Title:        v1.Order.Title,
+		Status:       v1.Order.Status,
+		Items:        []ItemV2{},
+		Total:        MoneyV2(v1.Order.Total),
+		CreatedAt:    v1.Order.CreatedAt,
+		OrderVersion: v1.Order.Version,
+	}
+	for _, item := range v1.Order.Items {
+		v2.Items = append(v2.Items, ItemV2{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: MoneyV2(item.UnitPrice),
+		})
+	}
+	return v2
+}
+
+func orderStatusChangedV1ToV2(v1 OrderEventV1) OrderStatusChangedV2 {
+	return OrderStatusChangedV2{
+		OrderID:        v1.Order.ID,
+		TenantID:       v1.Order.TenantID,
+		CustomerID:     v1.Order.CustomerID,
+		Status:         v1.Order.Status,
+		PreviousStatus: v1.PreviousStatus,
+		OrderVersion:   v1.Order.Version,
+	}
+}
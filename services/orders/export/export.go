@@ -0,0 +1,141 @@
+// Package export writes orders as CSV or NDJSON for bulk processing and reads
+// such files for import.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatCSV || f == FormatNDJSON
+}
+
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Columns are the CSV columns in the order they are written. Consumers rely
+// on the order, so new columns are only ever appended.
+var Columns = []string{
+	"id",
+	"customer_id",
+	"title",
+	"status",
+	"item_count",
+	"total_amount",
+	"currency",
+	"created_at",
+	"version",
+}
+
+// Writer writes orders one at a time. Call Flush when done.
+type Writer interface {
+	Write(order orders.Order) error
+	Flush() error
+}
+
+// NewWriter returns a Writer of the format. A CSV writer starts with a
+// header row.
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		err := writer.Write(Columns)
+		return csvWriter{writer}, err
+	case FormatNDJSON:
+		return ndjsonWriter{json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w csvWriter) Write(order orders.Order) error {
+	return w.writer.Write([]string{
+		strconv.Itoa(order.ID),
+		order.CustomerID,
+		order.Title,
+		string(order.Status),
+		strconv.Itoa(len(order.Items)),
+		strconv.FormatInt(order.Total.Amount, 10),
+		order.Total.Currency,
+		order.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.Itoa(order.Version),
+	})
+}
+
+func (w csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type itemJSON struct {
+	SKU       string    `json:"sku"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice moneyJSON `json:"unit_price"`
+}
+
+type orderJSON struct {
+	ID         int           `json:"id"`
+	CustomerID string        `json:"customer_id"`
+	Title      string        `json:"title"`
+	Status     orders.Status `json:"status"`
+	Items      []itemJSON    `json:"items"`
+	Total      moneyJSON     `json:"total"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Version    int           `json:"version"`
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w ndjsonWriter) Write(order orders.Order) error {
+	items := []itemJSON{}
+	for _, item := range order.Items {
+		items = append(items, itemJSON{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: moneyJSON(item.UnitPrice),
+		})
+	}
+	return w.encoder.Encode(orderJSON{
+		ID:         order.ID,
+		CustomerID: order.CustomerID,
+		Title:      order.Title,
+		Status:     order.Status,
+		Items:      items,
+		Total:      moneyJSON(order.Total),
+		CreatedAt:  order.CreatedAt.UTC(),
+		Version:    order.Version,
+	})
+}
+
+func (w ndjsonWriter) Flush() error {
+	return nil
+}
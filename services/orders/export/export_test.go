@@ -0,0 +1,90 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testOrders = []orders.Order{
+	{
+		ID:         1,
+		CustomerID: "alice",
+		Title:      "yellow duck",
+		Status:     orders.StatusPaid,
+		Items:      []orders.Item{{SKU: "duck", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}},
+		Total:      orders.Money{Amount: 300, Currency: "EUR"},
+		CreatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Version:    2,
+	},
+	{
+		ID:         2,
+		CustomerID: "bob",
+		Title:      `duck, "rubber"`,
+		Status:     orders.StatusCreated,
+		CreatedAt:  time.Date(2026, 1, 2, 4, 4, 5, 500_000_000, time.UTC),
+		Version:    1,
+	},
+}
+
+func write(t *testing.T, format Format, exported []orders.Order) string {
+	var output strings.Builder
+	writer, err := NewWriter(&output, format)
+	require.Nil(t, err)
+	for _, order := range exported {
+		require.Nil(t, writer.Write(order))
+	}
+	require.Nil(t, writer.Flush())
+	return output.String()
+}
+
+func TestWriter_WritesCSV(t *testing.T) {
+	output := write(t, FormatCSV, testOrders)
+
+	assert.Equal(t, "id,customer_id,title,status,item_count,total_amount,currency,created_at,version\n"+
+		"1,alice,yellow duck,paid,1,300,EUR,2026-01-02T03:04:05Z,2\n"+
+		"2,bob,\"duck, \"\"rubber\"\"\",created,0,0,,2026-01-02T04:04:05.5Z,1\n", output)
+}
+
+func TestWriter_WritesCSVHeaderWithoutOrders(t *testing.T) {
+	output := write(t, FormatCSV, nil)
+
+	assert.Equal(t, strings.Join(Columns, ",")+"\n", output)
+}
+
+func TestWriter_WritesNDJSON(t *testing.T) {
+	output := write(t, FormatNDJSON, testOrders)
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"id": 1,
+		"customer_id": "alice",
+		"title": "yellow duck",
+		"status": "paid",
+		"items": [{"sku": "duck", "quantity": 2, "unit_price": {"amount": 150, "currency": "EUR"}}],
+		"total": {"amount": 300, "currency": "EUR"},
+		"created_at": "2026-01-02T03:04:05Z",
+		"version": 2
+	}`, lines[0])
+	assert.JSONEq(t, `{
+		"id": 2,
+		"customer_id": "bob",
+		"title": "duck, \"rubber\"",
+		"status": "created",
+		"items": [],
+		"total": {"amount": 0, "currency": ""},
+		"created_at": "2026-01-02T04:04:05.5Z",
+		"version": 1
+	}`, lines[1])
+}
+
+func TestNewWriter_ReturnsErrorForUnknownFormat(t *testing.T) {
+	_, err := NewWriter(&strings.Builder{}, "xml")
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// maxLineLength limits the length of an NDJSON line.
+const maxLineLength = 1 << 20
+
+// ErrMalformedRecord is set as orders.ImportRecord.Err for records that can
+// not be parsed.
+var ErrMalformedRecord = errors.New("malformed record")
+
+// NewReader returns an orders.ImportSource reading the format. CSV files
+// start with a header naming the columns: title is required, customer_id
+// and items, a JSON array of items like in NDJSON, are optional, and other
+// columns such as those written by Writer are ignored.
+func NewReader(r io.Reader, format Format) (orders.ImportSource, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, maxLineLength)
+		return &ndjsonReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+type csvReader struct {
+	reader   *csv.Reader
+	title    int
+	customer int
+	items    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing CSV header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &csvReader{
+		reader:   reader,
+		title:    slices.Index(header, "title"),
+		customer: slices.Index(header, "customer_id"),
+		items:    slices.Index(header, "items"),
+	}
+	if result.title < 0 {
+		return nil, errors.New("missing title column in CSV header")
+	}
+	return result, nil
+}
+
+func (r *csvReader) Next() (orders.ImportRecord, error) {
+	row, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return orders.ImportRecord{
+			Line: parseErr.StartLine,
+			Err:  fmt.Errorf("%w: %w", ErrMalformedRecord, parseErr.Err),
+		}, nil
+	}
+	if err != nil {
+		return orders.ImportRecord{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	record := orders.ImportRecord{
+		Line:  line,
+		Title: row[r.title],
+	}
+	if r.customer >= 0 {
+		record.CustomerID = row[r.customer]
+	}
+	if r.items >= 0 && row[r.items] != "" {
+		record.Items, err = parseItems([]byte(row[r.items]))
+		if err != nil {
+			record.Err = fmt.Errorf("%w: items: %w", ErrMalformedRecord, err)
+		}
+	}
+	return record, nil
+}
+
+func parseItems(data []byte) ([]orders.Item, error) {
+	var parsed []itemJSON
+	err := json.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	return toItems(parsed), nil
+}
+
+func toItems(parsed []itemJSON) []orders.Item {
+	var items []orders.Item
+	for _, item := range parsed {
+		items = append(items, orders.Item{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: orders.Money(item.UnitPrice),
+		})
+	}
+	return items
+}
+
+// recordJSON is an NDJSON line. It accepts the lines written by Writer and
+// ignores the fields that are assigned on import.
+type recordJSON struct {
+	CustomerID string     `json:"customer_id"`
+	Title      string     `json:"title"`
+	Items      []itemJSON `json:"items"`
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Next() (orders.ImportRecord, error) {
+	for r.scanner.Scan() {
+		r.line += 1
+		if len(bytes.TrimSpace(r.scanner.Bytes())) == 0 {
+			continue
+		}
+		record := orders.ImportRecord{Line: r.line}
+		var parsed recordJSON
+		err := json.Unmarshal(r.scanner.Bytes(), &parsed)
+		if err != nil {
+			record.Err = fmt.Errorf("%w: %w", ErrMalformedRecord, err)
+			return record, nil
+		}
+		record.CustomerID = parsed.CustomerID
+		record.Title = parsed.Title
+		record.Items = toItems(parsed.Items)
+		return record, nil
+	}
+	err := r.scanner.Err()
+	if err != nil {
+		return orders.ImportRecord{}, err
+	}
+	return orders.ImportRecord{}, io.EOF
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, format Format, input string) []orders.ImportRecord {
+	reader, err := NewReader(strings.NewReader(input), format)
+	require.Nil(t, err)
+	var records []orders.ImportRecord
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		require.Nil(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestReader_ReadsCSV(t *testing.T) {
+	input := "customer_id,title,items,status\n" +
+		"alice,yellow duck,\"[{\"\"sku\"\":\"\"duck\"\",\"\"quantity\"\":2,\"\"unit_price\"\":{\"\"amount\"\":150,\"\"currency\"\":\"\"EUR\"\"}}]\",paid\n" +
+		"bob,\"rubber\nduck\",,created\n"
+
+	records := readAll(t, FormatCSV, input)
+
+	assert.Equal(t, []orders.ImportRecord{
+		{
+			Line:       2,
+			CustomerID: "alice",
+			Title:      "yellow duck",
+			Items:      []orders.Item{{SKU: "duck", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}},
+		},
+		{Line: 3, CustomerID: "bob", Title: "rubber\nduck"},
+	}, records)
+}
+
+func TestReader_ReadsCSVWrittenByWriter(t *testing.T) {
+	records := readAll(t, FormatCSV, write(t, FormatCSV, testOrders))
+
+	require.Len(t, records, 2)
+	assert.Equal(t, orders.ImportRecord{Line: 3, CustomerID: "bob", Title: `duck, "rubber"`}, records[1])
+}
+
+func TestReader_ReportsMalformedCSVRows(t *testing.T) {
+	input := "title,items\n" +
+		"duck\n" +
+		"goose,not json\n" +
+		"egg,\n"
+
+	records := readAll(t, FormatCSV, input)
+
+	require.Len(t, records, 3)
+	assert.Equal(t, 2, records[0].Line)
+	assert.True(t, errors.Is(records[0].Err, ErrMalformedRecord), records[0].Err)
+	assert.Equal(t, 3, records[1].Line)
+	assert.True(t, errors.Is(records[1].Err, ErrMalformedRecord), records[1].Err)
+	assert.Equal(t, orders.ImportRecord{Line: 4, Title: "egg"}, records[2])
+}
+
+func TestReader_RejectsCSVWithoutTitleColumn(t *testing.T) {
+	for _, input := range []string{"", "customer_id,items\n"} {
+		_, err := NewReader(strings.NewReader(input), FormatCSV)
+
+		assert.NotNil(t, err)
+	}
+}
+
+func TestReader_ReadsNDJSONWrittenByWriter(t *testing.T) {
+	records := readAll(t, FormatNDJSON, write(t, FormatNDJSON, testOrders))
+
+	assert.Equal(t, []orders.ImportRecord{
+		{Line: 1, CustomerID: "alice", Title: "yellow duck", Items: testOrders[0].Items},
+		{Line: 2, CustomerID: "bob", Title: `duck, "rubber"`},
+	}, records)
+}
+
+func TestReader_ReportsMalformedNDJSONLines(t *testing.T) {
+	input := `{"title": "duck"}` + "\n\n" + `{"title": ` + "\n" + `{"title": "egg"}`
+
+	records := readAll(t, FormatNDJSON, input)
+
+	require.Len(t, records, 3)
+	assert.Equal(t, orders.ImportRecord{Line: 1, Title: "duck"}, records[0])
+	assert.Equal(t, 3, records[1].Line)
+	assert.True(t, errors.Is(records[1].Err, ErrMalformedRecord), records[1].Err)
+	assert.Equal(t, orders.ImportRecord{Line: 4, Title: "egg"}, records[2])
+}
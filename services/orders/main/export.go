@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/export"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// runExport implements the export subcommand. It writes the orders of a
+// tenant to the output file, or to stdout if there is none.
+func runExport(ctx context.Context, logger *slog.Logger, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("orders export", flag.ContinueOnError)
+	tenant := flags.String("tenant", "", "tenant to export (required)")
+	format := flags.String("format", string(export.FormatCSV), "output format: csv or ndjson")
+	statuses := flags.String("status", "", "comma separated statuses to export")
+	from := flags.String("from", "", "export orders created at or after this RFC 3339 time")
+	to := flags.String("to", "", "export orders created before this RFC 3339 time")
+	output := flags.String("output", "", "file to write instead of stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *tenant == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("usage: orders export -tenant <id> [flags]")
+	}
+	if !export.Format(*format).IsValid() {
+		return fmt.Errorf("invalid format %q", *format)
+	}
+	var filter orders.ListFilter
+	if *statuses != "" {
+		for _, status := range strings.Split(*statuses, ",") {
+			filter.Statuses = append(filter.Statuses, orders.Status(status))
+		}
+	}
+	if *from != "" {
+		filter.CreatedFrom, err = time.Parse(time.RFC3339, *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from %q", *from)
+		}
+	}
+	if *to != "" {
+		filter.CreatedTo, err = time.Parse(time.RFC3339, *to)
+		if err != nil {
+			return fmt.Errorf("invalid -to %q", *to)
+		}
+	}
+
+	db, closeDatabase, err := openStorage(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase()
+	config, err := engineConfig(db)
+	if err != nil {
+		return err
+	}
+	engine := orders.NewEngine(config)
+
+	destination := stdout
+	var file *os.File
+	if *output != "" {
+		file, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		destination = file
+	}
+	buffered := bufio.NewWriter(destination)
+	writer, err := export.NewWriter(buffered, export.Format(*format))
+	if err != nil {
+		return err
+	}
+	exported := 0
+	principal := orders.Principal{TenantID: *tenant, Admin: true}
+	err = engine.ExportOrders(ctx, principal, filter, func(order orders.Order) error {
+		exported += 1
+		return writer.Write(order)
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	err = buffered.Flush()
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		err = file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	logger.Info("exported orders", "tenant", *tenant, "count", exported)
+	return nil
+}
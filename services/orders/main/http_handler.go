@@ -0,0 +1,685 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/export"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type ordersConfig struct {
+	engine orders.Engine
+}
+
+type httpHandlerMux struct {
+	mux *http.ServeMux
+}
+
+func (h httpHandlerMux) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	h.mux.ServeHTTP(responseWriter, request)
+}
+
+type httpHandlerMuxConfig struct {
+	logger *slog.Logger
+	orders ordersConfig
+}
+
+func newHttpHandlerMux(config httpHandlerMuxConfig) httpHandlerMux {
+	mux := http.NewServeMux()
+	mux.Handle("POST /orders", httpHandler{config.logger, handleCreateOrder, config.orders})
+	mux.Handle("POST /orders:batch", httpHandler{config.logger, handleCreateOrders, config.orders})
+	mux.Handle("POST /orders:import", httpHandler{config.logger, handleImportOrders, config.orders})
+	mux.Handle("GET /orders", httpHandler{config.logger, handleListOrders, config.orders})
+	mux.Handle("GET /orders/export", httpHandler{config.logger, handleExportOrders, config.orders})
+	mux.Handle("GET /orders/search", httpHandler{config.logger, handleSearchOrders, config.orders})
+	mux.Handle("GET /orders/{id}", httpHandler{config.logger, handleGetOrder, config.orders})
+	mux.Handle("PATCH /orders/{id}", httpHandler{config.logger, handleUpdateOrder, config.orders})
+	mux.Handle("POST /orders/{id}/status", httpHandler{config.logger, handleChangeOrderStatus, config.orders})
+	return httpHandlerMux{mux}
+}
+
+type handleFunc func(*slog.Logger, ordersConfig, http.ResponseWriter, *http.Request)
+
+type httpHandler struct {
+	logger *slog.Logger
+	handle handleFunc
+	orders ordersConfig
+}
+
+// The headers describe the caller. The API gateway removes them from client
+// requests and sets them from the verified bearer token, so the service must
+// only be reachable through the gateway. userRolesHeader is a comma separated
+// list.
+const (
+	tenantHeader    = "X-Tenant-ID"
+	userIDHeader    = "X-User-ID"
+	userRolesHeader = "X-User-Roles"
+	adminRole       = "admin"
+)
+
+func (h httpHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	err := orders.ValidateTenant(request.Header.Get(tenantHeader))
+	if err != nil {
+		h.logger.Info("rejected request without tenant", "method", request.Method, "url", request.URL.String())
+		http.Error(responseWriter, "Missing or invalid "+tenantHeader+" header", http.StatusBadRequest)
+		return
+	}
+	if request.Header.Get(userIDHeader) == "" {
+		h.logger.Info("rejected unauthenticated request", "method", request.Method, "url", request.URL.String())
+		http.Error(responseWriter, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.handle(h.logger, h.orders, responseWriter, request)
+}
+
+func principal(request *http.Request) orders.Principal {
+	principal := orders.Principal{
+		TenantID:   request.Header.Get(tenantHeader),
+		CustomerID: request.Header.Get(userIDHeader),
+	}
+	for _, role := range strings.Split(request.Header.Get(userRolesHeader), ",") {
+		if strings.TrimSpace(role) == adminRole {
+			principal.Admin = true
+		}
+	}
+	return principal
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type itemJSON struct {
+	SKU       string    `json:"sku"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice moneyJSON `json:"unit_price"`
+}
+
+type createOrderRequest struct {
+	Title string     `json:"title"`
+	Items []itemJSON `json:"items"`
+}
+
+func (r createOrderRequest) items() []orders.Item {
+	var items []orders.Item
+	for _, item := range r.Items {
+		items = append(items, orders.Item{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: orders.Money(item.UnitPrice),
+		})
+	}
+	return items
+}
+
+type changeOrderStatusRequest struct {
+	Status orders.Status `json:"status"`
+}
+
+type updateOrderRequest struct {
+	Title *string     `json:"title"`
+	Items *[]itemJSON `json:"items"`
+}
+
+func (r updateOrderRequest) update() orders.OrderUpdate {
+	update := orders.OrderUpdate{Title: r.Title}
+	if r.Items != nil {
+		items := createOrderRequest{Items: *r.Items}.items()
+		if items == nil {
+			items = []orders.Item{}
+		}
+		update.Items = &items
+	}
+	return update
+}
+
+type orderResponse struct {
+	ID        int           `json:"id"`
+	Title     string        `json:"title"`
+	Status    orders.Status `json:"status"`
+	Items     []itemJSON    `json:"items"`
+	Total     moneyJSON     `json:"total"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func newOrderResponse(order orders.Order) orderResponse {
+	items := []itemJSON{}
+	for _, item := range order.Items {
+		items = append(items, itemJSON{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: moneyJSON(item.UnitPrice),
+		})
+	}
+	return orderResponse{
+		ID:        order.ID,
+		Title:     order.Title,
+		Status:    order.Status,
+		Items:     items,
+		Total:     moneyJSON(order.Total),
+		CreatedAt: order.CreatedAt,
+	}
+}
+
+// batchResultResponse is the result for one order of a batch: the created
+// order, or the reasons why it is invalid.
+type batchResultResponse struct {
+	Status int              `json:"status"`
+	Order  *orderResponse   `json:"order,omitempty"`
+	Errors []fieldErrorJSON `json:"errors,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResultResponse `json:"results"`
+}
+
+type orderPageResponse struct {
+	Orders     []orderResponse `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func handleCreateOrder(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle create order", "method", request.Method, "url", request.URL.String())
+	var body createOrderRequest
+	err := json.NewDecoder(request.Body).Decode(&body)
+	if err != nil {
+		logger.Info("failed to decode request body", "error", err.Error())
+		http.Error(responseWriter, "Bad request", http.StatusBadRequest)
+		return
+	}
+	var order orders.Order
+	created := true
+	key := request.Header.Get("Idempotency-Key")
+	if key != "" {
+		order, created, err = config.engine.CreateOrderIdempotent(request.Context(), principal(request), key, body.Title, body.items())
+	} else {
+		order, err = config.engine.CreateOrder(request.Context(), principal(request), body.Title, body.items())
+	}
+	if errors.Is(err, orders.ErrInvalidIdempotencyKey) {
+		http.Error(responseWriter, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if errors.As(err, &orders.ValidationError{}) {
+		writeValidationProblem(logger, responseWriter, err)
+		return
+	}
+	if errors.Is(err, orders.ErrIdempotencyKeyReused) {
+		http.Error(responseWriter, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to create order", err)
+		return
+	}
+	if !created {
+		responseWriter.Header().Set("Idempotent-Replayed", "true")
+	}
+	responseWriter.Header().Set("Location", "/orders/"+strconv.Itoa(order.ID))
+	writeOrder(logger, responseWriter, http.StatusCreated, order)
+}
+
+func handleCreateOrders(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle create orders", "method", request.Method, "url", request.URL.String())
+	var body []createOrderRequest
+	err := json.NewDecoder(request.Body).Decode(&body)
+	if err != nil {
+		logger.Info("failed to decode request body", "error", err.Error())
+		http.Error(responseWriter, "Bad request", http.StatusBadRequest)
+		return
+	}
+	mode := orders.BatchMode(request.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = orders.BatchAtomic
+	}
+	requests := make([]orders.OrderRequest, len(body))
+	for idx, order := range body {
+		requests[idx] = orders.OrderRequest{Title: order.Title, Items: order.items()}
+	}
+	results, err := config.engine.CreateOrders(request.Context(), principal(request), requests, mode)
+	if errors.Is(err, orders.ErrInvalidBatch) {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.As(err, &orders.ValidationError{}) {
+		writeValidationProblem(logger, responseWriter, err)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to create orders", err, "count", len(requests))
+		return
+	}
+	code := http.StatusCreated
+	response := batchResponse{Results: make([]batchResultResponse, len(results))}
+	for idx, result := range results {
+		var validationErr orders.ValidationError
+		if errors.As(result.Err, &validationErr) {
+			code = http.StatusMultiStatus
+			response.Results[idx].Status = http.StatusUnprocessableEntity
+			for _, field := range validationErr.Fields {
+				response.Results[idx].Errors = append(response.Results[idx].Errors, fieldErrorJSON(field))
+			}
+			continue
+		}
+		order := newOrderResponse(result.Order)
+		response.Results[idx].Status = http.StatusCreated
+		response.Results[idx].Order = &order
+	}
+	writeJSON(logger, responseWriter, code, response)
+}
+
+func handleListOrders(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle list orders", "method", request.Method, "url", request.URL.String())
+	listRequest, err := parseListRequest(request.URL.Query())
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := config.engine.ListOrders(request.Context(), principal(request), listRequest)
+	if errors.Is(err, orders.ErrInvalidQuery) || errors.Is(err, orders.ErrInvalidCursor) {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to list orders", err)
+		return
+	}
+	writeOrderPage(logger, responseWriter, page)
+}
+
+func handleSearchOrders(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle search orders", "method", request.Method, "url", request.URL.String())
+	query := request.URL.Query()
+	searchRequest := orders.SearchRequest{
+		Query:  query.Get("q"),
+		Cursor: query.Get("cursor"),
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(responseWriter, fmt.Sprintf("invalid limit %q", value), http.StatusBadRequest)
+			return
+		}
+		searchRequest.Limit = limit
+	}
+	page, err := config.engine.SearchOrders(request.Context(), principal(request), searchRequest)
+	if errors.Is(err, orders.ErrInvalidQuery) || errors.Is(err, orders.ErrInvalidCursor) {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to search orders", err)
+		return
+	}
+	writeOrderPage(logger, responseWriter, page)
+}
+
+// handleExportOrders streams the orders. Errors after the first order was
+// written can not be reported with a status code anymore, so they abort the
+// response instead.
+func handleExportOrders(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle export orders", "method", request.Method, "url", request.URL.String())
+	query := request.URL.Query()
+	format := export.Format(query.Get("format"))
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if !format.IsValid() {
+		http.Error(responseWriter, fmt.Sprintf("invalid format %q", format), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseListFilter(query)
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Exports may take longer than the write timeout of the server.
+	_ = http.NewResponseController(responseWriter).SetWriteDeadline(time.Time{})
+	var writer export.Writer
+	start := func() error {
+		started, err := export.NewWriter(responseWriter, format)
+		if err != nil {
+			return err
+		}
+		responseWriter.Header().Set("Content-Type", format.ContentType())
+		writer = started
+		return nil
+	}
+	exported := 0
+	err = config.engine.ExportOrders(request.Context(), principal(request), filter, func(order orders.Order) error {
+		if writer == nil {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+		exported += 1
+		return writer.Write(order)
+	})
+	if err != nil && writer == nil {
+		if errors.Is(err, orders.ErrInvalidQuery) {
+			http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeServerError(logger, responseWriter, request, "failed to export orders", err)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to export orders", "error", err.Error(), "exported", exported)
+		panic(http.ErrAbortHandler)
+	}
+	if writer == nil {
+		err = start()
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		logger.Error("failed to write response body", "error", err.Error())
+	}
+}
+
+type importErrorResponse struct {
+	Line    int              `json:"line"`
+	Message string           `json:"message"`
+	Errors  []fieldErrorJSON `json:"errors,omitempty"`
+}
+
+type importReportResponse struct {
+	DryRun   bool                  `json:"dry_run"`
+	Records  int                   `json:"records"`
+	Imported int                   `json:"imported"`
+	Errors   []importErrorResponse `json:"errors"`
+}
+
+func newImportReportResponse(report orders.ImportReport, dryRun bool) importReportResponse {
+	response := importReportResponse{
+		DryRun:   dryRun,
+		Records:  report.Records,
+		Imported: report.Imported,
+		Errors:   []importErrorResponse{},
+	}
+	for _, importErr := range report.Errors {
+		row := importErrorResponse{Line: importErr.Line, Message: importErr.Err.Error()}
+		var validationErr orders.ValidationError
+		if errors.As(importErr.Err, &validationErr) {
+			row.Message = "invalid order"
+			for _, field := range validationErr.Fields {
+				row.Errors = append(row.Errors, fieldErrorJSON(field))
+			}
+		}
+		response.Errors = append(response.Errors, row)
+	}
+	return response
+}
+
+// handleImportOrders imports the file in the request body and reports the
+// records that were not imported.
+func handleImportOrders(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle import orders", "method", request.Method, "url", request.URL.String())
+	query := request.URL.Query()
+	format := export.Format(query.Get("format"))
+	if format == "" {
+		format = export.FormatCSV
+	}
+	dryRun := query.Get("dry_run") == "true"
+	// Uploads may take longer than the timeouts of the server.
+	controller := http.NewResponseController(responseWriter)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+	source, err := export.NewReader(request.Body, format)
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := config.engine.ImportOrders(request.Context(), principal(request), source, dryRun)
+	if errors.Is(err, orders.ErrForbidden) {
+		http.Error(responseWriter, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to import orders", err, "imported", report.Imported)
+		return
+	}
+	writeJSON(logger, responseWriter, http.StatusOK, newImportReportResponse(report, dryRun))
+}
+
+func writeOrderPage(logger *slog.Logger, responseWriter http.ResponseWriter, page orders.OrderPage) {
+	response := orderPageResponse{
+		Orders:     []orderResponse{},
+		NextCursor: page.NextCursor,
+	}
+	for _, order := range page.Orders {
+		response.Orders = append(response.Orders, newOrderResponse(order))
+	}
+	writeJSON(logger, responseWriter, http.StatusOK, response)
+}
+
+func parseListRequest(query url.Values) (orders.ListRequest, error) {
+	var err error
+	listRequest := orders.ListRequest{
+		Sort:   orders.SortOrder(query.Get("sort")),
+		Cursor: query.Get("cursor"),
+	}
+	listRequest.Filter, err = parseListFilter(query)
+	if err != nil {
+		return listRequest, err
+	}
+	if value := query.Get("limit"); value != "" {
+		listRequest.Limit, err = strconv.Atoi(value)
+		if err != nil || listRequest.Limit <= 0 {
+			return listRequest, fmt.Errorf("invalid limit %q", value)
+		}
+	}
+	return listRequest, nil
+}
+
+func parseListFilter(query url.Values) (orders.ListFilter, error) {
+	var err error
+	filter := orders.ListFilter{
+		TitleContains: query.Get("title"),
+		CustomerID:    query.Get("customer"),
+	}
+	for _, value := range query["status"] {
+		for _, status := range strings.Split(value, ",") {
+			filter.Statuses = append(filter.Statuses, orders.Status(status))
+		}
+	}
+	if value := query.Get("created_from"); value != "" {
+		filter.CreatedFrom, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_from %q", value)
+		}
+	}
+	if value := query.Get("created_to"); value != "" {
+		filter.CreatedTo, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_to %q", value)
+		}
+	}
+	return filter, nil
+}
+
+func handleGetOrder(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle get order", "method", request.Method, "url", request.URL.String())
+	id, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		http.Error(responseWriter, "Not found", http.StatusNotFound)
+		return
+	}
+	order, err := config.engine.GetOrder(request.Context(), principal(request), id)
+	if errors.Is(err, orders.ErrNotFound) {
+		http.Error(responseWriter, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to get order", err, "id", id)
+		return
+	}
+	writeOrder(logger, responseWriter, http.StatusOK, order)
+}
+
+func handleChangeOrderStatus(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle change order status", "method", request.Method, "url", request.URL.String())
+	id, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		http.Error(responseWriter, "Not found", http.StatusNotFound)
+		return
+	}
+	var body changeOrderStatusRequest
+	err = json.NewDecoder(request.Body).Decode(&body)
+	if err != nil || !body.Status.IsValid() {
+		http.Error(responseWriter, "Bad request", http.StatusBadRequest)
+		return
+	}
+	order, err := config.engine.ChangeOrderStatus(request.Context(), principal(request), id, body.Status)
+	if errors.Is(err, orders.ErrNotFound) {
+		http.Error(responseWriter, "Not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, orders.ErrForbidden) {
+		http.Error(responseWriter, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if errors.As(err, &orders.TransitionError{}) || errors.Is(err, orders.ErrVersionMismatch) {
+		http.Error(responseWriter, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to change order status", err, "id", id)
+		return
+	}
+	writeOrder(logger, responseWriter, http.StatusOK, order)
+}
+
+func handleUpdateOrder(logger *slog.Logger, config ordersConfig, responseWriter http.ResponseWriter, request *http.Request) {
+	logger.Info("handle update order", "method", request.Method, "url", request.URL.String())
+	id, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		http.Error(responseWriter, "Not found", http.StatusNotFound)
+		return
+	}
+	ifMatch := request.Header.Get("If-Match")
+	if ifMatch == "" {
+		http.Error(responseWriter, "Precondition required", http.StatusPreconditionRequired)
+		return
+	}
+	version, ok := parseETag(ifMatch)
+	if !ok {
+		http.Error(responseWriter, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	}
+	var body updateOrderRequest
+	err = json.NewDecoder(request.Body).Decode(&body)
+	if err != nil {
+		http.Error(responseWriter, "Bad request", http.StatusBadRequest)
+		return
+	}
+	order, err := config.engine.UpdateOrder(request.Context(), principal(request), id, version, body.update())
+	if errors.Is(err, orders.ErrNotFound) {
+		http.Error(responseWriter, "Not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, orders.ErrVersionMismatch) {
+		http.Error(responseWriter, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, orders.ErrOrderNotEditable) {
+		http.Error(responseWriter, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.As(err, &orders.ValidationError{}) {
+		writeValidationProblem(logger, responseWriter, err)
+		return
+	}
+	if err != nil {
+		writeServerError(logger, responseWriter, request, "failed to update order", err, "id", id)
+		return
+	}
+	writeOrder(logger, responseWriter, http.StatusOK, order)
+}
+
+// writeServerError reports an unexpected error. Errors caused by the client
+// going away, which cancels the queries of its request, are not failures.
+func writeServerError(logger *slog.Logger, responseWriter http.ResponseWriter, request *http.Request, message string, err error, args ...any) {
+	if request.Context().Err() != nil {
+		logger.Info("request cancelled", "error", err.Error())
+		return
+	}
+	logger.Error(message, append([]any{"error", err.Error()}, args...)...)
+	http.Error(responseWriter, "Internal server error", http.StatusInternalServerError)
+}
+
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func parseETag(value string) (int, bool) {
+	unquoted, found := strings.CutPrefix(value, `"`)
+	if !found {
+		return 0, false
+	}
+	unquoted, found = strings.CutSuffix(unquoted, `"`)
+	if !found {
+		return 0, false
+	}
+	version, err := strconv.Atoi(unquoted)
+	return version, err == nil
+}
+
+func writeOrder(logger *slog.Logger, responseWriter http.ResponseWriter, code int, order orders.Order) {
+	responseWriter.Header().Set("ETag", formatETag(order.Version))
+	writeJSON(logger, responseWriter, code, newOrderResponse(order))
+}
+
+type fieldErrorJSON struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// problemResponse is an RFC 9457 problem document.
+type problemResponse struct {
+	Type   string           `json:"type"`
+	Title  string           `json:"title"`
+	Status int              `json:"status"`
+	Detail string           `json:"detail,omitempty"`
+	Errors []fieldErrorJSON `json:"errors,omitempty"`
+}
+
+func writeValidationProblem(logger *slog.Logger, responseWriter http.ResponseWriter, err error) {
+	var validationErr orders.ValidationError
+	errors.As(err, &validationErr)
+	problem := problemResponse{
+		Type:   "/problems/validation-error",
+		Title:  "Invalid order",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "One or more fields are invalid.",
+	}
+	for _, field := range validationErr.Fields {
+		problem.Errors = append(problem.Errors, fieldErrorJSON(field))
+	}
+	responseWriter.Header().Set("Content-Type", "application/problem+json")
+	responseWriter.WriteHeader(problem.Status)
+	err = json.NewEncoder(responseWriter).Encode(problem)
+	if err != nil {
+		logger.Error("failed to write response body", "error", err.Error())
+	}
+}
+
+func writeJSON(logger *slog.Logger, responseWriter http.ResponseWriter, code int, value any) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(code)
+	err := json.NewEncoder(responseWriter).Encode(value)
+	if err != nil {
+		logger.Error("failed to write response body", "error", err.Error())
+	}
+}
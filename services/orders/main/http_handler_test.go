@@ -0,0 +1,1011 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/mrstecklo/micropet/services/orders/orders_mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+const (
+	testTenant   = "acme"
+	testCustomer = "alice"
+)
+
+func newRequest(method string, target string, body io.Reader) *http.Request {
+	request := httptest.NewRequest(method, target, body)
+	request.Header.Set(tenantHeader, testTenant)
+	request.Header.Set(userIDHeader, testCustomer)
+	return request
+}
+
+type httpHandlerFixture struct {
+	mux              httpHandlerMux
+	responseRecorder *httptest.ResponseRecorder
+	mockCtrl         *gomock.Controller
+	databaseMock     *orders_mock.MockDatabase
+}
+
+func setUpHttpHandlerTest(t *testing.T) httpHandlerFixture {
+	logger := createLogger(os.Stdout)
+	mockCtrl := gomock.NewController(t, gomock.WithOverridableExpectations())
+	databaseMock := orders_mock.NewMockDatabase(mockCtrl)
+	engine := orders.NewEngine(orders.Config{
+		Database: databaseMock,
+		Now:      func() time.Time { return testTime },
+	})
+	mux := newHttpHandlerMux(httpHandlerMuxConfig{
+		logger: logger,
+		orders: ordersConfig{engine},
+	})
+	return httpHandlerFixture{
+		mux:              mux,
+		responseRecorder: httptest.NewRecorder(),
+		mockCtrl:         mockCtrl,
+		databaseMock:     databaseMock,
+	}
+}
+
+func TestHttpHandler_CreateOrderReturnsCreated(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), orders.Order{TenantID: testTenant, CustomerID: testCustomer, Title: "something", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1}).
+		Return(42, nil)
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": "something"}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusCreated, f.responseRecorder.Code)
+	assert.Equal(t, "/orders/42", f.responseRecorder.Header().Get("Location"))
+	assert.Equal(t, "application/json", f.responseRecorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id": 42, "title": "something", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrderReturnsItemsAndTotal(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), orders.Order{
+			TenantID:   testTenant,
+			CustomerID: testCustomer,
+			Title:      "groceries",
+			Status:     orders.StatusCreated,
+			Items: []orders.Item{
+				{SKU: "milk", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}},
+			},
+			Total:     orders.Money{Amount: 300, Currency: "EUR"},
+			CreatedAt: testTime,
+			Version:   1,
+		}).
+		Return(7, nil)
+
+	body := `{"title": "groceries", "items": [{"sku": "milk", "quantity": 2, "unit_price": {"amount": 150, "currency": "EUR"}}]}`
+	request := newRequest("POST", "/orders", strings.NewReader(body))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusCreated, f.responseRecorder.Code)
+	assert.JSONEq(t, `{
+		"id": 7,
+		"title": "groceries",
+		"status": "created",
+		"items": [{"sku": "milk", "quantity": 2, "unit_price": {"amount": 150, "currency": "EUR"}}],
+		"total": {"amount": 300, "currency": "EUR"},
+		"created_at": "2026-01-02T03:04:05Z"
+	}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrderReturnsUnprocessableEntityOnInvalidItems(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	body := `{"title": "groceries", "items": [{"sku": "milk", "quantity": 0, "unit_price": {"amount": 150, "currency": "EUR"}}]}`
+	request := newRequest("POST", "/orders", strings.NewReader(body))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, f.responseRecorder.Code)
+	assert.Equal(t, "application/problem+json", f.responseRecorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "/problems/validation-error",
+		"title": "Invalid order",
+		"status": 422,
+		"detail": "One or more fields are invalid.",
+		"errors": [{"field": "items[0].quantity", "code": "not_positive", "message": "must be positive"}]
+	}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrdersReturnsCreated(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), []orders.Order{
+			{TenantID: testTenant, CustomerID: testCustomer, Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1},
+			{TenantID: testTenant, CustomerID: testCustomer, Title: "goose", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1},
+		}).
+		Return([]int{4, 5}, nil)
+
+	request := newRequest("POST", "/orders:batch", strings.NewReader(`[{"title": "duck"}, {"title": "goose"}]`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusCreated, f.responseRecorder.Code)
+	assert.JSONEq(t, `{"results": [
+		{"status": 201, "order": {"id": 4, "title": "duck", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}},
+		{"status": 201, "order": {"id": 5, "title": "goose", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}}
+	]}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrdersRejectsAtomicBatchWithInvalidOrder(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := newRequest("POST", "/orders:batch", strings.NewReader(`[{"title": "duck"}, {"title": ""}]`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, f.responseRecorder.Code)
+	assert.JSONEq(t, `{
+		"type": "/problems/validation-error",
+		"title": "Invalid order",
+		"status": 422,
+		"detail": "One or more fields are invalid.",
+		"errors": [{"field": "[1].title", "code": "required", "message": "must not be empty"}]
+	}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrdersReportsResultsOfPartialBatch(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), gomock.Len(1)).
+		Return([]int{5}, nil)
+
+	request := newRequest("POST", "/orders:batch?mode=partial", strings.NewReader(`[{"title": ""}, {"title": "goose"}]`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusMultiStatus, f.responseRecorder.Code)
+	assert.JSONEq(t, `{"results": [
+		{"status": 422, "errors": [{"field": "title", "code": "required", "message": "must not be empty"}]},
+		{"status": 201, "order": {"id": 5, "title": "goose", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}}
+	]}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrdersReturnsBadRequest(t *testing.T) {
+	data := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"MalformedBody", "/orders:batch", `{"title": "duck"}`},
+		{"EmptyBatch", "/orders:batch", `[]`},
+		{"UnknownMode", "/orders:batch?mode=best_effort", `[{"title": "duck"}]`},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				CreateOrders(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			request := newRequest("POST", d.target, strings.NewReader(d.body))
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_CreateOrderReturnsUnprocessableEntityOnEmptyTitle(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": ""}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, f.responseRecorder.Code)
+	assert.JSONEq(t, `{
+		"type": "/problems/validation-error",
+		"title": "Invalid order",
+		"status": 422,
+		"detail": "One or more fields are invalid.",
+		"errors": [{"field": "title", "code": "required", "message": "must not be empty"}]
+	}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrderUsesIdempotencyKey(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), orders.Order{TenantID: testTenant, CustomerID: testCustomer, Title: "something", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1}, gomock.Any()).
+		Return(42, true, nil)
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": "something"}`))
+	request.Header.Set("Idempotency-Key", "some key")
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusCreated, f.responseRecorder.Code)
+	assert.Equal(t, "/orders/42", f.responseRecorder.Header().Get("Location"))
+	assert.Empty(t, f.responseRecorder.Header().Get("Idempotent-Replayed"))
+}
+
+func TestHttpHandler_CreateOrderReplaysIdempotentRequest(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(42, false, nil)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 42).
+		Return(orders.Order{ID: 42, CustomerID: testCustomer, Title: "something", Status: orders.StatusCreated, CreatedAt: testTime}, nil)
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": "something"}`))
+	request.Header.Set("Idempotency-Key", "some key")
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusCreated, f.responseRecorder.Code)
+	assert.Equal(t, "/orders/42", f.responseRecorder.Header().Get("Location"))
+	assert.Equal(t, "true", f.responseRecorder.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, `{"id": 42, "title": "something", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_CreateOrderReturnsUnprocessableEntityOnReusedIdempotencyKey(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(0, false, orders.ErrIdempotencyKeyReused)
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": "something"}`))
+	request.Header.Set("Idempotency-Key", "some key")
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_CreateOrderReturnsBadRequestOnMalformedBody(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": `))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_CreateOrderReturnsInternalServerError(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), gomock.Any()).
+		Return(0, errors.New("oh, no!"))
+
+	request := newRequest("POST", "/orders", strings.NewReader(`{"title": "something"}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusInternalServerError, f.responseRecorder.Code)
+	assert.Equal(t, "Internal server error\n", f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_GetOrderReturnsOrder(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 1421).
+		Return(orders.Order{ID: 1421, CustomerID: testCustomer, Title: "duckling", Status: orders.StatusPaid, CreatedAt: testTime, Version: 1}, nil)
+
+	request := newRequest("GET", "/orders/1421", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.Equal(t, `"1"`, f.responseRecorder.Header().Get("ETag"))
+	assert.JSONEq(t, `{"id": 1421, "title": "duckling", "status": "paid", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_GetOrderReturnsNotFound(t *testing.T) {
+	data := []struct {
+		name   string
+		target string
+	}{
+		{
+			"UnknownId",
+			"/orders/1",
+		},
+		{
+			"MalformedId",
+			"/orders/duck",
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, gomock.Any()).
+				Return(orders.Order{}, orders.ErrNotFound).
+				AnyTimes()
+
+			request := newRequest("GET", d.target, nil)
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusNotFound, f.responseRecorder.Code)
+			assert.Equal(t, "Not found\n", f.responseRecorder.Body.String())
+		})
+	}
+}
+
+func TestHttpHandler_GetOrderReturnsInternalServerError(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, gomock.Any()).
+		Return(orders.Order{}, errors.New("oh, no!"))
+
+	request := newRequest("GET", "/orders/1", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusInternalServerError, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_RejectsRequestWithoutTenant(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := httptest.NewRequest("GET", "/orders/1", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_RejectsRequestWithoutUser(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := httptest.NewRequest("GET", "/orders/1", nil)
+	request.Header.Set(tenantHeader, testTenant)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusUnauthorized, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_GetOrderOfOtherCustomerReturnsNotFound(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 1).
+		Return(orders.Order{ID: 1, CustomerID: "bob", Title: "duck", Status: orders.StatusCreated}, nil)
+
+	request := newRequest("GET", "/orders/1", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusNotFound, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_AdminListsOrdersOfAllCustomers(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{Sort: orders.SortByCreatedAtDesc, Limit: orders.DefaultPageSize + 1}).
+		Return(nil, nil)
+
+	request := newRequest("GET", "/orders", nil)
+	request.Header.Set(userRolesHeader, "support, admin")
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_GetOrderCancelsQueryWhenClientDisconnects(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 1).
+		DoAndReturn(func(ctx context.Context, tenant string, id int) (orders.Order, error) {
+			cancel()
+			<-ctx.Done()
+			return orders.Order{}, ctx.Err()
+		})
+
+	request := newRequest("GET", "/orders/1", nil).WithContext(ctx)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Empty(t, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ChangeOrderStatusReturnsOrder(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(orders.Order{ID: 5, CustomerID: testCustomer, Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), orders.Order{ID: 5, CustomerID: testCustomer, Title: "duck", Status: orders.StatusConfirmed, CreatedAt: testTime, Version: 2}, orders.StatusCreated, 1).
+		Return(nil)
+
+	request := newAdminRequest("POST", "/orders/5/status", strings.NewReader(`{"status": "confirmed"}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.JSONEq(t, `{"id": 5, "title": "duck", "status": "confirmed", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ChangeOrderStatusReturnsErrors(t *testing.T) {
+	data := []struct {
+		name   string
+		target string
+		body   string
+		order  orders.Order
+		err    error
+		code   int
+	}{
+		{
+			"UnknownStatus",
+			"/orders/5/status",
+			`{"status": "lost"}`,
+			orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusCreated},
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"MalformedBody",
+			"/orders/5/status",
+			`{"status": `,
+			orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusCreated},
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"IllegalTransition",
+			"/orders/5/status",
+			`{"status": "delivered"}`,
+			orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusCreated},
+			nil,
+			http.StatusConflict,
+		},
+		{
+			"UnknownId",
+			"/orders/5/status",
+			`{"status": "confirmed"}`,
+			orders.Order{},
+			orders.ErrNotFound,
+			http.StatusNotFound,
+		},
+		{
+			"DatabaseError",
+			"/orders/5/status",
+			`{"status": "confirmed"}`,
+			orders.Order{},
+			errors.New("oh, no!"),
+			http.StatusInternalServerError,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, gomock.Any()).
+				Return(d.order, d.err).
+				AnyTimes()
+			f.databaseMock.EXPECT().
+				UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			request := newRequest("POST", d.target, strings.NewReader(d.body))
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, d.code, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_ChangeOrderStatusReturnsConflictOnConcurrentChange(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusCreated, Version: 3}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), gomock.Any(), orders.StatusCreated, 3).
+		Return(orders.ErrVersionMismatch)
+
+	request := newAdminRequest("POST", "/orders/5/status", strings.NewReader(`{"status": "confirmed"}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusConflict, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ChangeOrderStatusLetsCustomersCancel(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusPaid, Version: 1}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), gomock.Any(), orders.StatusPaid, 1).
+		Return(nil)
+
+	request := newRequest("POST", "/orders/5/status", strings.NewReader(`{"status": "cancelled"}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ChangeOrderStatusIsForbiddenForCustomers(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusPaid, Version: 1}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := newRequest("POST", "/orders/5/status", strings.NewReader(`{"status": "shipped"}`))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusForbidden, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ListOrdersReturnsPage(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{Filter: orders.ListFilter{CustomerID: testCustomer}, Sort: orders.SortByIDAsc, Limit: 2}).
+		Return([]orders.Order{
+			{ID: 1, Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime},
+			{ID: 2, Title: "pickle", Status: orders.StatusPaid, CreatedAt: testTime},
+		}, nil)
+
+	request := newRequest("GET", "/orders?sort=id&limit=1", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	cursor := orders.Cursor{Sort: orders.SortByIDAsc, CreatedAt: testTime, ID: 1}.Encode()
+	assert.JSONEq(t, `{
+		"orders": [{"id": 1, "title": "duck", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}],
+		"next_cursor": "`+cursor+`"
+	}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ListOrdersParsesFilter(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{
+			Filter: orders.ListFilter{
+				Statuses:      []orders.Status{orders.StatusPaid, orders.StatusShipped, orders.StatusCreated},
+				CreatedFrom:   testTime,
+				CreatedTo:     testTime.Add(time.Hour),
+				TitleContains: "duck",
+				CustomerID:    testCustomer,
+			},
+			Sort:  orders.SortByCreatedAtDesc,
+			Limit: orders.DefaultPageSize + 1,
+		}).
+		Return(nil, nil)
+
+	target := "/orders?status=paid,shipped&status=created&created_from=2026-01-02T03:04:05Z&created_to=2026-01-02T04:04:05Z&title=duck"
+	request := newRequest("GET", target, nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.JSONEq(t, `{"orders": []}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ExportOrdersStreamsCSV(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	filter := orders.ListFilter{
+		Statuses:    []orders.Status{orders.StatusPaid},
+		CreatedFrom: testTime,
+		CreatedTo:   testTime.Add(time.Hour),
+		CustomerID:  testCustomer,
+	}
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, filter, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ orders.ListFilter, visit func(orders.Order) error) error {
+			return visit(orders.Order{ID: 1, CustomerID: testCustomer, Title: "duck", Status: orders.StatusPaid, CreatedAt: testTime, Version: 1})
+		})
+
+	request := newRequest("GET", "/orders/export?status=paid&created_from=2026-01-02T03:04:05Z&created_to=2026-01-02T04:04:05Z", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", f.responseRecorder.Header().Get("Content-Type"))
+	assert.Equal(t, "id,customer_id,title,status,item_count,total_amount,currency,created_at,version\n"+
+		"1,alice,duck,paid,0,0,,2026-01-02T03:04:05Z,1\n", f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ExportOrdersStreamsNDJSON(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ orders.ListFilter, visit func(orders.Order) error) error {
+			for id := range 3 {
+				err := visit(orders.Order{ID: id + 1, CustomerID: testCustomer, Status: orders.StatusCreated})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	request := newRequest("GET", "/orders/export?format=ndjson", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.Equal(t, "application/x-ndjson", f.responseRecorder.Header().Get("Content-Type"))
+	assert.Equal(t, 3, strings.Count(f.responseRecorder.Body.String(), "\n"))
+}
+
+func TestHttpHandler_ExportOrdersWritesHeaderWithoutOrders(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	request := newRequest("GET", "/orders/export", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.Equal(t, "id,customer_id,title,status,item_count,total_amount,currency,created_at,version\n", f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ExportOrdersReturnsBadRequest(t *testing.T) {
+	data := []string{
+		"/orders/export?format=xml",
+		"/orders/export?status=lost",
+		"/orders/export?created_from=yesterday",
+	}
+	for _, target := range data {
+		t.Run(target, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				ExportOrders(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			request := newRequest("GET", target, nil)
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_ExportOrdersReturnsServerErrorBeforeFirstOrder(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, gomock.Any(), gomock.Any()).
+		Return(errors.New("oh, no!"))
+
+	request := newRequest("GET", "/orders/export", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusInternalServerError, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ExportOrdersAbortsResponseOnLaterError(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ orders.ListFilter, visit func(orders.Order) error) error {
+			err := visit(orders.Order{ID: 1, CustomerID: testCustomer, Status: orders.StatusCreated})
+			if err != nil {
+				return err
+			}
+			return errors.New("oh, no!")
+		})
+
+	request := newRequest("GET", "/orders/export", nil)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		f.mux.ServeHTTP(f.responseRecorder, request)
+	})
+}
+
+func newAdminRequest(method string, target string, body io.Reader) *http.Request {
+	request := newRequest(method, target, body)
+	request.Header.Set(userRolesHeader, adminRole)
+	return request
+}
+
+func TestHttpHandler_ImportOrdersReportsInvalidRecords(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ImportOrders(gomock.Any(), []orders.Order{
+			{TenantID: testTenant, CustomerID: "bob", Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1},
+		}).
+		Return(nil)
+
+	body := "customer_id,title\nbob,duck\nbob,\n"
+	request := newAdminRequest("POST", "/orders:import", strings.NewReader(body))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.JSONEq(t, `{
+		"dry_run": false,
+		"records": 2,
+		"imported": 1,
+		"errors": [{"line": 3, "message": "invalid order", "errors": [{"field": "title", "code": "required", "message": "must not be empty"}]}]
+	}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_ImportOrdersOnlyValidatesOnDryRun(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ImportOrders(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	body := `{"customer_id": "bob", "title": "duck"}` + "\n" + `{"title": `
+	request := newAdminRequest("POST", "/orders:import?format=ndjson&dry_run=true", strings.NewReader(body))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	var response struct {
+		DryRun   bool `json:"dry_run"`
+		Records  int  `json:"records"`
+		Imported int  `json:"imported"`
+		Errors   []struct {
+			Line int `json:"line"`
+		} `json:"errors"`
+	}
+	require.Nil(t, json.Unmarshal(f.responseRecorder.Body.Bytes(), &response))
+	assert.True(t, response.DryRun)
+	assert.Equal(t, 2, response.Records)
+	assert.Equal(t, 0, response.Imported)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, 2, response.Errors[0].Line)
+}
+
+func TestHttpHandler_ImportOrdersIsForbiddenForCustomers(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		ImportOrders(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	request := newRequest("POST", "/orders:import", strings.NewReader("title\nduck\n"))
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusForbidden, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ImportOrdersReturnsBadRequest(t *testing.T) {
+	data := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"UnknownFormat", "/orders:import?format=xml", "title\nduck\n"},
+		{"MissingTitleColumn", "/orders:import", "name\nduck\n"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+
+			request := newAdminRequest("POST", d.target, strings.NewReader(d.body))
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_SearchOrdersReturnsPage(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		SearchOrders(gomock.Any(), testTenant, orders.SearchQuery{Text: "rubber duck", CustomerID: testCustomer}, orders.Page{Limit: 2}).
+		Return([]orders.Order{
+			{ID: 2, Title: "rubber duck", Status: orders.StatusCreated, CreatedAt: testTime},
+			{ID: 1, Title: "yellow rubber duck", Status: orders.StatusPaid, CreatedAt: testTime},
+		}, nil)
+
+	request := newRequest("GET", "/orders/search?q=rubber+duck&limit=1", nil)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	var response struct {
+		Orders []struct {
+			ID int `json:"id"`
+		} `json:"orders"`
+		NextCursor string `json:"next_cursor"`
+	}
+	require.Nil(t, json.Unmarshal(f.responseRecorder.Body.Bytes(), &response))
+	assert.Len(t, response.Orders, 1)
+	assert.Equal(t, 2, response.Orders[0].ID)
+	assert.NotEmpty(t, response.NextCursor)
+}
+
+func TestHttpHandler_SearchOrdersReturnsBadRequest(t *testing.T) {
+	data := []string{
+		"/orders/search",
+		"/orders/search?q=duck&limit=many",
+		"/orders/search?q=duck&cursor=!!!",
+	}
+	for _, target := range data {
+		t.Run(target, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				SearchOrders(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			request := newRequest("GET", target, nil)
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_ListOrdersReturnsBadRequest(t *testing.T) {
+	data := []struct {
+		name   string
+		target string
+	}{
+		{"MalformedLimit", "/orders?limit=many"},
+		{"ZeroLimit", "/orders?limit=0"},
+		{"LimitTooLarge", "/orders?limit=1000"},
+		{"MalformedDate", "/orders?created_from=yesterday"},
+		{"UnknownStatus", "/orders?status=lost"},
+		{"UnknownSort", "/orders?sort=title"},
+		{"MalformedCursor", "/orders?cursor=duck"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				ListOrders(gomock.Any(), testTenant, gomock.Any()).
+				Times(0)
+
+			request := newRequest("GET", d.target, nil)
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_UpdateOrderReturnsUpdatedOrder(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(orders.Order{ID: 5, CustomerID: testCustomer, Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime, Version: 3}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrder(gomock.Any(), orders.Order{ID: 5, CustomerID: testCustomer, Title: "goose", Status: orders.StatusCreated, CreatedAt: testTime, Version: 4}, 3).
+		Return(nil)
+
+	request := newRequest("PATCH", "/orders/5", strings.NewReader(`{"title": "goose"}`))
+	request.Header.Set("If-Match", `"3"`)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, f.responseRecorder.Code)
+	assert.Equal(t, `"4"`, f.responseRecorder.Header().Get("ETag"))
+	assert.JSONEq(t, `{"id": 5, "title": "goose", "status": "created", "items": [], "total": {"amount": 0, "currency": ""}, "created_at": "2026-01-02T03:04:05Z"}`, f.responseRecorder.Body.String())
+}
+
+func TestHttpHandler_UpdateOrderReturnsErrors(t *testing.T) {
+	data := []struct {
+		name    string
+		ifMatch string
+		body    string
+		order   orders.Order
+		err     error
+		code    int
+	}{
+		{
+			"MissingIfMatch",
+			"",
+			`{"title": "goose"}`,
+			orders.Order{ID: 5, CustomerID: testCustomer, Version: 3},
+			nil,
+			http.StatusPreconditionRequired,
+		},
+		{
+			"MalformedIfMatch",
+			"3",
+			`{"title": "goose"}`,
+			orders.Order{ID: 5, CustomerID: testCustomer, Version: 3},
+			nil,
+			http.StatusPreconditionFailed,
+		},
+		{
+			"StaleIfMatch",
+			`"2"`,
+			`{"title": "goose"}`,
+			orders.Order{ID: 5, CustomerID: testCustomer, Version: 3},
+			nil,
+			http.StatusPreconditionFailed,
+		},
+		{
+			"MalformedBody",
+			`"3"`,
+			`{"title": `,
+			orders.Order{ID: 5, CustomerID: testCustomer, Version: 3},
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"ItemsOfPaidOrder",
+			`"3"`,
+			`{"items": []}`,
+			orders.Order{ID: 5, CustomerID: testCustomer, Status: orders.StatusPaid, Version: 3},
+			nil,
+			http.StatusConflict,
+		},
+		{
+			"UnknownId",
+			`"3"`,
+			`{"title": "goose"}`,
+			orders.Order{},
+			orders.ErrNotFound,
+			http.StatusNotFound,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, gomock.Any()).
+				Return(d.order, d.err).
+				AnyTimes()
+			f.databaseMock.EXPECT().
+				UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			request := newRequest("PATCH", "/orders/5", strings.NewReader(d.body))
+			if d.ifMatch != "" {
+				request.Header.Set("If-Match", d.ifMatch)
+			}
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, d.code, f.responseRecorder.Code)
+		})
+	}
+}
+
+func TestHttpHandler_UpdateOrderReturnsPreconditionFailedOnConcurrentUpdate(t *testing.T) {
+	f := setUpHttpHandlerTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(orders.Order{ID: 5, CustomerID: testCustomer, Version: 3}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrder(gomock.Any(), gomock.Any(), 3).
+		Return(orders.ErrVersionMismatch)
+
+	request := newRequest("PATCH", "/orders/5", strings.NewReader(`{"title": "goose"}`))
+	request.Header.Set("If-Match", `"3"`)
+	f.mux.ServeHTTP(f.responseRecorder, request)
+
+	assert.Equal(t, http.StatusPreconditionFailed, f.responseRecorder.Code)
+}
+
+func TestHttpHandler_ReturnsMethodNotAllowed(t *testing.T) {
+	data := []struct {
+		name   string
+		method string
+		target string
+	}{
+		{
+			"PostId",
+			"POST",
+			"/orders/123",
+		},
+		{
+			"DeleteId",
+			"DELETE",
+			"/orders/123",
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpHttpHandlerTest(t)
+
+			request := newRequest(d.method, d.target, nil)
+			f.mux.ServeHTTP(f.responseRecorder, request)
+
+			assert.Equal(t, http.StatusMethodNotAllowed, f.responseRecorder.Code)
+		})
+	}
+}
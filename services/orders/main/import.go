@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mrstecklo/micropet/services/orders/export"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// runImport implements the import subcommand. It imports the orders of the
+// file, or of stdin if there is none, and prints a line for every rejected
+// record.
+func runImport(ctx context.Context, logger *slog.Logger, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("orders import", flag.ContinueOnError)
+	tenant := flags.String("tenant", "", "tenant to import into (required)")
+	format := flags.String("format", string(export.FormatCSV), "input format: csv or ndjson")
+	dryRun := flags.Bool("dry-run", false, "only validate the orders")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *tenant == "" || flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("usage: orders import -tenant <id> [flags] [file]")
+	}
+
+	source := stdin
+	if flags.NArg() == 1 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		source = file
+	}
+	reader, err := export.NewReader(source, export.Format(*format))
+	if err != nil {
+		return err
+	}
+
+	db, closeDatabase, err := openStorage(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase()
+	config, err := engineConfig(db)
+	if err != nil {
+		return err
+	}
+	engine := orders.NewEngine(config)
+
+	principal := orders.Principal{TenantID: *tenant, Admin: true}
+	report, err := engine.ImportOrders(ctx, principal, reader, *dryRun)
+	for _, rejected := range report.Errors {
+		fmt.Fprintf(stdout, "line %d: %s\n", rejected.Line, rejected.Err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("imported orders",
+		"tenant", *tenant,
+		"dry_run", *dryRun,
+		"records", report.Records,
+		"imported", report.Imported,
+		"rejected", len(report.Errors))
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("rejected %d of %d records", len(report.Errors), report.Records)
+	}
+	return nil
+}
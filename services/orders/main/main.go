@@ -1,31 +1,196 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/mrstecklo/micropet/services/orders/database"
+	"github.com/mrstecklo/micropet/services/orders/orders"
 )
 
 func main() {
-	logger := createLogger()
+	logOutput := os.Stdout
+	if len(os.Args) > 1 && (os.Args[1] == "export" || os.Args[1] == "import") {
+		// Keep stdout for the exported orders and the import report.
+		logOutput = os.Stderr
+	}
+	logger := createLogger(logOutput)
 	err := godotenv.Load()
-	if err != nil {
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Info("no .env file, using process environment")
+	} else if err != nil {
 		logger.Error("failed to load .env file", "error", err.Error())
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(ctx, logger, os.Args[2:])
+		if err != nil {
+			logger.Error("failed to migrate database", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		err = runExport(ctx, logger, os.Args[2:], os.Stdout)
+		if err != nil {
+			logger.Error("failed to export orders", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err = runImport(ctx, logger, os.Args[2:], os.Stdin, os.Stdout)
+		if err != nil {
+			logger.Error("failed to import orders", "error", err.Error())
+			os.Exit(1)
+		}
 		return
 	}
 
-	dsn := os.Getenv("DATABASE_URL")
-	db, err := database.NewDatabase(dsn, logger)
+	db, closeDatabase, err := openStorage(ctx, logger)
 	if err != nil {
-		return
+		logger.Error("failed to open database", "error", err.Error())
+		os.Exit(1)
+	}
+	defer closeDatabase()
+
+	config, err := engineConfig(db)
+	if err != nil {
+		logger.Error("invalid configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	engine := orders.NewEngine(config)
+	messagingSystem, closeMessaging, err := openMessaging(ctx, logger, db)
+	if err != nil {
+		logger.Error("failed to open messaging system", "error", err.Error())
+		os.Exit(1)
+	}
+	retention, err := getEnvDuration("OUTBOX_RETENTION")
+	if err != nil {
+		logger.Error("invalid configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	relay := orders.NewRelay(orders.RelayConfig{
+		Outbox:    db,
+		Messaging: messagingSystem,
+		Logger:    logger,
+		Retention: retention,
+	})
+	handler := newHttpHandlerMux(httpHandlerMuxConfig{
+		logger: logger,
+		orders: ordersConfig{engine},
+	})
+	server := http.Server{
+		Addr:         getEnv("HTTP_ADDRESS", ":8081"),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 90 * time.Second,
+		Handler:      handler,
+	}
+
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(ctx)
+	}()
+	purgeDone := make(chan struct{})
+	go func() {
+		defer close(purgeDone)
+		purgeIdempotencyKeys(ctx, logger, db, time.Hour)
+	}()
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		logger.Info("Shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			logger.Error("failed to shut down server", "error", err.Error())
+		}
+	}()
+
+	logger.Info("Starting server", "address", server.Addr)
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		logger.Error("server", slog.String("error", err.Error()))
+	}
+	stop()
+	<-shutdownDone
+	<-relayDone
+	<-purgeDone
+	closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = closeMessaging(closeCtx)
+	if err != nil {
+		logger.Error("failed to close messaging system", "error", err.Error())
+	}
+	logger.Info("Server closed")
+}
+
+// purgeIdempotencyKeys deletes the expired idempotency keys every interval
+// until ctx is cancelled.
+func purgeIdempotencyKeys(ctx context.Context, logger *slog.Logger, db orders.Database, interval time.Duration) {
+	for {
+		deleted, err := db.DeleteExpiredIdempotencyKeys(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("failed to delete expired idempotency keys", "error", err.Error())
+		}
+		if deleted > 0 {
+			logger.Debug("deleted expired idempotency keys", "count", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// engineConfig reads the order validation rules from the environment.
+func engineConfig(db orders.Database) (orders.Config, error) {
+	config := orders.Config{
+		Database: db,
+	}
+	var err error
+	config.MaxTitleLength, err = getEnvInt("ORDERS_MAX_TITLE_LENGTH")
+	if err != nil {
+		return config, err
+	}
+	pattern := os.Getenv("ORDERS_TITLE_PATTERN")
+	if pattern != "" {
+		config.TitlePattern, err = regexp.Compile(pattern)
+		if err != nil {
+			return config, fmt.Errorf("ORDERS_TITLE_PATTERN: %w", err)
+		}
+	}
+	return config, nil
+}
+
+func getEnv(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
 	}
-	defer db.Close()
+	return value
 }
 
-func createLogger() *slog.Logger {
+func createLogger(output io.Writer) *slog.Logger {
 	options := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := slog.NewTextHandler(os.Stdout, options)
+	handler := slog.NewTextHandler(output, options)
 	return slog.New(handler)
 }
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/mrstecklo/micropet/services/orders/database"
+	"github.com/mrstecklo/micropet/services/orders/messaging"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/redis/go-redis/v9"
+)
+
+// openMessaging opens the messaging system selected by MESSAGING_DRIVER:
+// "log" (default) only logs the events, "memory" publishes them to an
+// in-process broker, "postgres" notifies them through the Postgres
+// database, "nats" publishes them to the NATS JetStream at NATS_URL and
+// "redis" appends them to the Redis streams at REDIS_URL. The returned
+// function closes it once the relay stopped.
+func openMessaging(ctx context.Context, logger *slog.Logger, db storage) (orders.MessagingSystem, func(ctx context.Context) error, error) {
+	driver := getEnv("MESSAGING_DRIVER", "log")
+	switch driver {
+	case "log":
+		return messaging.NewLogMessagingSystem(logger), func(context.Context) error { return nil }, nil
+	case "memory":
+		logger.Info("using in-process broker")
+		config, err := brokerConfig(logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		broker := messaging.NewBroker(config)
+		err = subscribeLogger(broker, messaging.NewLogMessagingSystem(logger))
+		if err != nil {
+			return nil, nil, err
+		}
+		return broker, broker.Close, nil
+	case "postgres":
+		postgres, ok := db.(database.Database)
+		if !ok {
+			return nil, nil, errors.New(`MESSAGING_DRIVER "postgres" requires DATABASE_DRIVER "postgres"`)
+		}
+		notify := database.NewNotifyMessagingSystem(postgres)
+		stopListener, err := listenLogger(ctx, logger, notify)
+		if err != nil {
+			return nil, nil, err
+		}
+		return notify, stopListener, nil
+	case "nats":
+		return openJetStream(ctx, logger)
+	case "redis":
+		return openRedis(logger)
+	default:
+		return nil, nil, fmt.Errorf("unknown MESSAGING_DRIVER %q", driver)
+	}
+}
+
+// openJetStream connects to NATS_URL and publishes to the stream named by
+// NATS_STREAM.
+func openJetStream(ctx context.Context, logger *slog.Logger) (orders.MessagingSystem, func(ctx context.Context) error, error) {
+	url := getEnv("NATS_URL", nats.DefaultURL)
+	logger.Info("connecting to NATS", "url", url)
+	conn, err := nats.Connect(url, nats.Name("orders"))
+	if err != nil {
+		return nil, nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	duplicateWindow, err := getEnvDuration("NATS_DUPLICATE_WINDOW")
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	m, err := messaging.NewJetStreamMessagingSystem(ctx, messaging.JetStreamConfig{
+		JetStream:       js,
+		Logger:          logger,
+		Stream:          os.Getenv("NATS_STREAM"),
+		DuplicateWindow: duplicateWindow,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return m, func(context.Context) error { return conn.Drain() }, nil
+}
+
+// openRedis connects to REDIS_URL and caps the streams at
+// REDIS_STREAM_MAX_LEN entries.
+func openRedis(logger *slog.Logger) (orders.MessagingSystem, func(ctx context.Context) error, error) {
+	options, err := redis.ParseURL(getEnv("REDIS_URL", "redis://localhost:6379/0"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	maxLen, err := getEnvInt("REDIS_STREAM_MAX_LEN")
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Info("using Redis streams", "address", options.Addr)
+	client := redis.NewClient(options)
+	m := messaging.NewRedisMessagingSystem(messaging.RedisConfig{
+		Client: client,
+		Logger: logger,
+		MaxLen: int64(maxLen),
+	})
+	return m, func(context.Context) error { return client.Close() }, nil
+}
+
+// brokerConfig reads the in-process broker settings from the environment.
+func brokerConfig(logger *slog.Logger) (messaging.BrokerConfig, error) {
+	config := messaging.BrokerConfig{
+		Logger:   logger,
+		Overflow: messaging.OverflowPolicy(getEnv("MESSAGING_OVERFLOW", string(messaging.OverflowBlock))),
+	}
+	if !config.Overflow.IsValid() {
+		return config, fmt.Errorf("MESSAGING_OVERFLOW must be block, drop or error, got %q", os.Getenv("MESSAGING_OVERFLOW"))
+	}
+	var err error
+	config.QueueSize, err = getEnvInt("MESSAGING_QUEUE_SIZE")
+	return config, err
+}
+
+// listenLogger logs the events notified from now on until the returned
+// function is called.
+func listenLogger(ctx context.Context, logger *slog.Logger, notify database.NotifyMessagingSystem) (func(ctx context.Context) error, error) {
+	after, err := notify.LatestEventID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listener := database.NewListener(database.ListenerConfig{
+		DSN:    os.Getenv("DATABASE_URL"),
+		Logger: logger,
+		After:  after,
+	})
+	log := messaging.NewLogMessagingSystem(logger)
+	listenCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(listenCtx, func(ctx context.Context, event orders.Event) error {
+			if event.Type == orders.EventOrderStatusChanged {
+				return log.PublishOrderStatusChanged(ctx, event.Order, event.PreviousStatus, event.CreatedAt)
+			}
+			return log.PublishOrderCreated(ctx, event.Order)
+		})
+	}()
+	return func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, nil
+}
+
+// subscribeLogger logs the events published to the broker, so they are
+// visible when nothing else subscribes.
+func subscribeLogger(broker *messaging.Broker, log messaging.LogMessagingSystem) error {
+	_, err := broker.Subscribe(messaging.TopicOrderCreated, func(ctx context.Context, message messaging.Message) error {
+		return log.PublishOrderCreated(ctx, message.Order)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = broker.Subscribe(messaging.TopicOrderStatusChanged, func(ctx context.Context, message messaging.Message) error {
+		return log.PublishOrderStatusChanged(ctx, message.Order, message.PreviousStatus, message.ChangedAt)
+	})
+	return err
+}
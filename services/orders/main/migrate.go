@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/mrstecklo/micropet/services/orders/database"
+)
+
+var errUsage = errors.New("usage: orders migrate [up | down [steps] | to <version> | version]")
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(ctx context.Context, logger *slog.Logger, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+		args = args[1:]
+	}
+	config, err := databaseConfig(logger)
+	if err != nil {
+		return err
+	}
+	db, err := database.NewDatabase(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch {
+	case command == "up" && len(args) == 0:
+		err = db.Migrate(ctx)
+	case command == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				return errUsage
+			}
+		}
+		err = db.MigrateDown(ctx, steps)
+	case command == "to" && len(args) == 1:
+		version, parseErr := strconv.Atoi(args[0])
+		if parseErr != nil {
+			return errUsage
+		}
+		err = db.MigrateTo(ctx, version)
+	case command == "version" && len(args) == 0:
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version %d of %d\n", version, database.LatestSchemaVersion())
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/database"
+	"github.com/mrstecklo/micropet/services/orders/memory"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type storage interface {
+	orders.Database
+	orders.Outbox
+}
+
+// openStorage opens the database selected by DATABASE_DRIVER: "postgres"
+// (default) connects to DATABASE_URL, "memory" keeps orders in memory.
+func openStorage(ctx context.Context, logger *slog.Logger) (storage, func(), error) {
+	driver := getEnv("DATABASE_DRIVER", "postgres")
+	switch driver {
+	case "memory":
+		logger.Info("using in-memory database")
+		return memory.NewDatabase(), func() {}, nil
+	case "postgres":
+		config, err := databaseConfig(logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		config.Migrate = getEnv("DATABASE_MIGRATE", "false") == "true"
+		db, err := database.NewDatabase(ctx, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, db.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown DATABASE_DRIVER %q", driver)
+	}
+}
+
+// databaseConfig reads the Postgres settings from the environment. Unset
+// settings are left to the database package defaults.
+func databaseConfig(logger *slog.Logger) (database.Config, error) {
+	config := database.Config{
+		DSN:    os.Getenv("DATABASE_URL"),
+		Logger: logger,
+	}
+	if config.DSN == "" {
+		return config, errors.New("DATABASE_URL is not set")
+	}
+	var errs [6]error
+	config.MaxOpenConns, errs[0] = getEnvInt("DATABASE_MAX_OPEN_CONNS")
+	config.MaxIdleConns, errs[1] = getEnvInt("DATABASE_MAX_IDLE_CONNS")
+	config.ConnMaxLifetime, errs[2] = getEnvDuration("DATABASE_CONN_MAX_LIFETIME")
+	config.ConnMaxIdleTime, errs[3] = getEnvDuration("DATABASE_CONN_MAX_IDLE_TIME")
+	config.StatementTimeout, errs[4] = getEnvDuration("DATABASE_STATEMENT_TIMEOUT")
+	config.PingAttempts, errs[5] = getEnvInt("DATABASE_PING_ATTEMPTS")
+	return config, errors.Join(errs[:]...)
+}
+
+func getEnvInt(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+	}
+	return parsed, nil
+}
+
+func getEnvDuration(key string) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration, got %q", key, value)
+	}
+	return parsed, nil
+}
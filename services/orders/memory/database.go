@@ -0,0 +1,310 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type keyID struct {
+	tenant string
+	key    string
+}
+
+type idempotencyKey struct {
+	fingerprint string
+	orderID     int
+	expiresAt   time.Time
+}
+
+type storedEvent struct {
+	event       orders.Event
+	delivered   bool
+	deliveredAt time.Time
+}
+
+// Database is an in-memory orders.Database and orders.Outbox. It is safe for
+// concurrent use and loses its contents when the process exits.
+type Database struct {
+	mutex       sync.Mutex
+	orders      map[int]orders.Order
+	lastID      int
+	events      []storedEvent
+	lastEventID int64
+	keys        map[keyID]idempotencyKey
+	now         func() time.Time
+}
+
+func NewDatabase() *Database {
+	return &Database{
+		orders: make(map[int]orders.Order),
+		keys:   make(map[keyID]idempotencyKey),
+		now:    time.Now,
+	}
+}
+
+func copyOrder(order orders.Order) orders.Order {
+	order.Items = slices.Clone(order.Items)
+	return order
+}
+
+// find returns the stored order with the id if it belongs to the tenant.
+func (db *Database) find(tenant string, id int) (orders.Order, bool) {
+	order, ok := db.orders[id]
+	if !ok || order.TenantID != tenant {
+		return orders.Order{}, false
+	}
+	return order, true
+}
+
+func (db *Database) GetOrder(ctx context.Context, tenant string, id int) (orders.Order, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	order, ok := db.find(tenant, id)
+	if !ok {
+		return orders.Order{}, orders.ErrNotFound
+	}
+	return copyOrder(order), nil
+}
+
+func (db *Database) CreateOrder(ctx context.Context, order orders.Order) (int, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.insertOrder(order), nil
+}
+
+func (db *Database) CreateOrderWithKey(ctx context.Context, order orders.Order, key orders.IdempotencyKey) (int, bool, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	now := db.now()
+	storedID := keyID{order.TenantID, key.Key}
+	stored, ok := db.keys[storedID]
+	if ok && now.Before(stored.expiresAt) {
+		if stored.fingerprint != key.Fingerprint {
+			return 0, false, orders.ErrIdempotencyKeyReused
+		}
+		return stored.orderID, false, nil
+	}
+	id := db.insertOrder(order)
+	db.keys[storedID] = idempotencyKey{
+		fingerprint: key.Fingerprint,
+		orderID:     id,
+		expiresAt:   now.Add(key.TTL),
+	}
+	return id, true, nil
+}
+
+func (db *Database) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	now := db.now()
+	deleted := int64(0)
+	for id, key := range db.keys {
+		if !now.Before(key.expiresAt) {
+			delete(db.keys, id)
+			deleted += 1
+		}
+	}
+	return deleted, nil
+}
+
+func (db *Database) CreateOrders(ctx context.Context, created []orders.Order) ([]int, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	ids := make([]int, len(created))
+	for idx, order := range created {
+		ids[idx] = db.insertOrder(order)
+	}
+	return ids, nil
+}
+
+func (db *Database) ImportOrders(ctx context.Context, imported []orders.Order) error {
+	_, err := db.CreateOrders(ctx, imported)
+	return err
+}
+
+func (db *Database) insertOrder(order orders.Order) int {
+	db.lastID += 1
+	order = copyOrder(order)
+	order.ID = db.lastID
+	db.orders[order.ID] = order
+	db.recordEvent(orders.EventOrderCreated, order, "")
+	return order.ID
+}
+
+func (db *Database) recordEvent(eventType orders.EventType, order orders.Order, previous orders.Status) {
+	db.lastEventID += 1
+	db.events = append(db.events, storedEvent{
+		event: orders.Event{
+			ID:             db.lastEventID,
+			Type:           eventType,
+			Order:          copyOrder(order),
+			PreviousStatus: previous,
+			CreatedAt:      db.now().UTC(),
+		},
+	})
+}
+
+func (db *Database) UpdateOrderStatus(ctx context.Context, order orders.Order, previous orders.Status, version int) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	stored, ok := db.find(order.TenantID, order.ID)
+	if !ok {
+		return orders.ErrNotFound
+	}
+	if stored.Status != previous {
+		return orders.TransitionError{From: stored.Status, To: order.Status}
+	}
+	if stored.Version != version {
+		return orders.ErrVersionMismatch
+	}
+	stored.Status = order.Status
+	stored.Version = order.Version
+	db.orders[order.ID] = stored
+	db.recordEvent(orders.EventOrderStatusChanged, order, previous)
+	return nil
+}
+
+func (db *Database) UpdateOrder(ctx context.Context, order orders.Order, version int) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	stored, ok := db.find(order.TenantID, order.ID)
+	if !ok {
+		return orders.ErrNotFound
+	}
+	if stored.Version != version {
+		return orders.ErrVersionMismatch
+	}
+	stored.Title = order.Title
+	stored.Items = slices.Clone(order.Items)
+	stored.Total = order.Total
+	stored.Version = order.Version
+	db.orders[order.ID] = stored
+	return nil
+}
+
+func (db *Database) ListOrders(ctx context.Context, tenant string, query orders.ListQuery) ([]orders.Order, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	var found []orders.Order
+	for _, order := range db.orders {
+		if order.TenantID == tenant && matches(order, query.Filter) && follows(order, query.Sort, query.After) {
+			found = append(found, copyOrder(order))
+		}
+	}
+	slices.SortFunc(found, func(a orders.Order, b orders.Order) int {
+		return compare(a, b.CreatedAt, b.ID, query.Sort)
+	})
+	if len(found) > query.Limit {
+		found = found[:query.Limit]
+	}
+	return found, nil
+}
+
+// ExportOrders visits a snapshot of the matching orders, so visit may call
+// the database.
+func (db *Database) ExportOrders(ctx context.Context, tenant string, filter orders.ListFilter, visit func(orders.Order) error) error {
+	found, err := db.ListOrders(ctx, tenant, orders.ListQuery{Filter: filter, Sort: orders.SortByIDAsc, Limit: math.MaxInt})
+	if err != nil {
+		return err
+	}
+	for _, order := range found {
+		err = visit(order)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matches(order orders.Order, filter orders.ListFilter) bool {
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, order.Status) {
+		return false
+	}
+	if !filter.CreatedFrom.IsZero() && order.CreatedAt.Before(filter.CreatedFrom) {
+		return false
+	}
+	if !filter.CreatedTo.IsZero() && !order.CreatedAt.Before(filter.CreatedTo) {
+		return false
+	}
+	if filter.TitleContains != "" &&
+		!strings.Contains(strings.ToLower(order.Title), strings.ToLower(filter.TitleContains)) {
+		return false
+	}
+	if filter.CustomerID != "" && order.CustomerID != filter.CustomerID {
+		return false
+	}
+	return true
+}
+
+func follows(order orders.Order, sort orders.SortOrder, cursor *orders.Cursor) bool {
+	return cursor == nil || compare(order, cursor.CreatedAt, cursor.ID, sort) > 0
+}
+
+// compare orders the order relative to the position given by createdAt and
+// id in the sort order.
+func compare(order orders.Order, createdAt time.Time, id int, sort orders.SortOrder) int {
+	switch sort {
+	case orders.SortByIDDesc:
+		return cmp.Compare(id, order.ID)
+	case orders.SortByCreatedAtAsc:
+		return cmp.Or(order.CreatedAt.Compare(createdAt), cmp.Compare(order.ID, id))
+	case orders.SortByCreatedAtDesc:
+		return cmp.Or(createdAt.Compare(order.CreatedAt), cmp.Compare(id, order.ID))
+	default:
+		return cmp.Compare(order.ID, id)
+	}
+}
+
+func (db *Database) PendingEvents(ctx context.Context, limit int) ([]orders.Event, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	var pending []orders.Event
+	for _, stored := range db.events {
+		if len(pending) == limit {
+			break
+		}
+		if !stored.delivered {
+			pending = append(pending, stored.event)
+		}
+	}
+	return pending, nil
+}
+
+func (db *Database) MarkEventDelivered(ctx context.Context, id int64) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	for idx := range db.events {
+		if db.events[idx].event.ID == id {
+			db.events[idx].delivered = true
+			db.events[idx].deliveredAt = db.now()
+		}
+	}
+	for len(db.events) > 0 && db.events[0].delivered {
+		db.events = db.events[1:]
+	}
+	return nil
+}
+
+// PruneDeliveredEvents deletes the delivered events still kept because an
+// earlier event is pending. The others are deleted as soon as they are
+// delivered.
+func (db *Database) PruneDeliveredEvents(ctx context.Context, before time.Time) (int64, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	pruned := int64(0)
+	db.events = slices.DeleteFunc(db.events, func(stored storedEvent) bool {
+		prune := stored.delivered && stored.deliveredAt.Before(before)
+		if prune {
+			pruned += 1
+		}
+		return prune
+	})
+	return pruned, nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/databasetest"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase_Conformance(t *testing.T) {
+	databasetest.Run(t, databasetest.Backend{
+		Open: func(t *testing.T) orders.Database {
+			return NewDatabase()
+		},
+	})
+}
+
+func TestDatabase_GetOrderReturnsCopy(t *testing.T) {
+	db := NewDatabase()
+	created := databasetest.NewOrder("something")
+	created.Items = []orders.Item{{SKU: "milk", Quantity: 2}}
+	id, err := db.CreateOrder(t.Context(), created)
+	require.Nil(t, err)
+	created.Items[0].SKU = "changed"
+
+	order, err := db.GetOrder(t.Context(), created.TenantID, id)
+	require.Nil(t, err)
+	order.Items[0].Quantity = 5
+	stored, err := db.GetOrder(t.Context(), created.TenantID, id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.Item{{SKU: "milk", Quantity: 2}}, stored.Items)
+}
+
+func TestDatabase_CreateOrderAssignsIncreasingIdsConcurrently(t *testing.T) {
+	db := NewDatabase()
+	var wg sync.WaitGroup
+	ids := make([]int, 50)
+	for idx := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := db.CreateOrder(t.Context(), databasetest.NewOrder("something"))
+			assert.Nil(t, err)
+			ids[idx] = id
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		assert.False(t, seen[id])
+		assert.True(t, id >= 1 && id <= len(ids))
+		seen[id] = true
+	}
+	id, err := db.CreateOrder(t.Context(), databasetest.NewOrder("something"))
+	assert.Nil(t, err)
+	assert.Equal(t, len(ids)+1, id)
+}
+
+func TestDatabase_CreateOrderWithKeyHonoursTTL(t *testing.T) {
+	db := NewDatabase()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	db.now = func() time.Time { return now }
+	key := orders.IdempotencyKey{Key: "key", Fingerprint: "abc", TTL: time.Hour}
+	id1, created1, err := db.CreateOrderWithKey(t.Context(), databasetest.NewOrder("something"), key)
+	require.Nil(t, err)
+
+	now = now.Add(59 * time.Minute)
+	id2, created2, err := db.CreateOrderWithKey(t.Context(), databasetest.NewOrder("something"), key)
+	require.Nil(t, err)
+	_, _, reuseErr := db.CreateOrderWithKey(t.Context(), databasetest.NewOrder("something"), orders.IdempotencyKey{Key: "key", Fingerprint: "def"})
+	now = now.Add(time.Minute)
+	id3, created3, err := db.CreateOrderWithKey(t.Context(), databasetest.NewOrder("something"), key)
+	require.Nil(t, err)
+
+	assert.True(t, created1)
+	assert.False(t, created2)
+	assert.Equal(t, id1, id2)
+	assert.Equal(t, orders.ErrIdempotencyKeyReused, reuseErr)
+	assert.True(t, created3)
+	assert.NotEqual(t, id1, id3)
+}
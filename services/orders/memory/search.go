@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// words splits the text into lower case words.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// rank returns how often the terms occur in the title, or zero if the title
+// misses any of them.
+func rank(title string, terms []string) int {
+	titleWords := words(title)
+	rank := 0
+	for _, term := range terms {
+		count := 0
+		for _, word := range titleWords {
+			if word == term {
+				count += 1
+			}
+		}
+		if count == 0 {
+			return 0
+		}
+		rank += count
+	}
+	return rank
+}
+
+// SearchOrders matches whole words of the title without stemming or the
+// operators of the web search syntax.
+func (db *Database) SearchOrders(ctx context.Context, tenant string, query orders.SearchQuery, page orders.Page) ([]orders.Order, error) {
+	terms := words(query.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	type result struct {
+		order orders.Order
+		rank  int
+	}
+	var results []result
+	for _, order := range db.orders {
+		if order.TenantID != tenant || (query.CustomerID != "" && order.CustomerID != query.CustomerID) {
+			continue
+		}
+		rank := rank(order.Title, terms)
+		if rank > 0 {
+			results = append(results, result{order, rank})
+		}
+	}
+	slices.SortFunc(results, func(a result, b result) int {
+		return cmp.Or(cmp.Compare(b.rank, a.rank), cmp.Compare(b.order.ID, a.order.ID))
+	})
+	var found []orders.Order
+	for idx := page.Offset; idx < len(results) && len(found) < page.Limit; idx++ {
+		found = append(found, copyOrder(results[idx].order))
+	}
+	return found, nil
+}
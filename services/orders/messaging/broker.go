@@ -0,0 +1,272 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type Topic string
+
+const (
+	TopicOrderCreated       Topic = "orders.created"
+	TopicOrderStatusChanged Topic = "orders.status_changed"
+)
+
+// Message is an event of an order. Messages received from NATS or Redis
+// carry only what the event schemas describe: for status changes the order
+// has its id, tenant, customer, status and version.
+type Message struct {
+	Topic Topic
+	Order orders.Order
+	// PreviousStatus and ChangedAt are set for TopicOrderStatusChanged.
+	PreviousStatus orders.Status
+	ChangedAt      time.Time
+}
+
+// Handler processes the messages of a subscription one at a time. Errors
+// are logged, the message is not redelivered.
+type Handler func(ctx context.Context, message Message) error
+
+// OverflowPolicy decides what a publish does when the queue of a subscriber
+// is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until the subscriber makes room.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDrop drops the message for that subscriber.
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowError fails the publish with ErrQueueFull, so the Relay
+	// retries it later.
+	OverflowError OverflowPolicy = "error"
+)
+
+func (p OverflowPolicy) IsValid() bool {
+	return p == OverflowBlock || p == OverflowDrop || p == OverflowError
+}
+
+var (
+	ErrQueueFull    = errors.New("subscriber queue is full")
+	ErrBrokerClosed = errors.New("broker is closed")
+)
+
+// Broker is an in-process orders.MessagingSystem. Every subscriber has its
+// own bounded queue and goroutine, so a slow subscriber only delays others
+// when the overflow policy is OverflowBlock.
+type Broker struct {
+	logger    *slog.Logger
+	queueSize int
+	overflow  OverflowPolicy
+	// ctx is passed to handlers and cancelled when Close gives up draining.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mutex         sync.RWMutex
+	closed        bool
+	subscriptions map[Topic][]*subscription
+	publishing    sync.WaitGroup
+	running       sync.WaitGroup
+}
+
+type subscription struct {
+	topic   Topic
+	handler Handler
+	queue   chan Message
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// Subscribe calls handler for every message published to topic until
+// unsubscribe is called or the broker is closed. Messages already queued
+// are still handled after unsubscribe.
+func (b *Broker) Subscribe(topic Topic, handler Handler) (unsubscribe func(), err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+	sub := &subscription{
+		topic:   topic,
+		handler: handler,
+		queue:   make(chan Message, b.queueSize),
+		stop:    make(chan struct{}),
+	}
+	b.subscriptions[topic] = append(b.subscriptions[topic], sub)
+	b.running.Add(1)
+	go b.run(sub)
+	return func() { b.unsubscribe(sub) }, nil
+}
+
+func (b *Broker) unsubscribe(sub *subscription) {
+	b.mutex.Lock()
+	subs := b.subscriptions[sub.topic]
+	for i, other := range subs {
+		if other == sub {
+			b.subscriptions[sub.topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	b.mutex.Unlock()
+	sub.once.Do(func() { close(sub.stop) })
+}
+
+// run handles the messages of sub until it is stopped and its queue is
+// empty.
+func (b *Broker) run(sub *subscription) {
+	defer b.running.Done()
+	for {
+		select {
+		case message := <-sub.queue:
+			b.handle(sub, message)
+		case <-sub.stop:
+			for {
+				select {
+				case message := <-sub.queue:
+					b.handle(sub, message)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *Broker) handle(sub *subscription, message Message) {
+	err := sub.handler(b.ctx, message)
+	if err != nil {
+		b.logger.Error("failed to handle message", "topic", message.Topic, "order_id", message.Order.ID, "error", err.Error())
+	}
+}
+
+// Publish queues the message for every subscriber of its topic.
+func (b *Broker) Publish(ctx context.Context, message Message) error {
+	b.mutex.RLock()
+	if b.closed {
+		b.mutex.RUnlock()
+		return ErrBrokerClosed
+	}
+	subs := b.subscriptions[message.Topic]
+	b.publishing.Add(1)
+	b.mutex.RUnlock()
+	defer b.publishing.Done()
+
+	var errs []error
+	for _, sub := range subs {
+		err := b.enqueue(ctx, sub, message)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Broker) enqueue(ctx context.Context, sub *subscription, message Message) error {
+	select {
+	case sub.queue <- message:
+		return nil
+	default:
+	}
+	switch b.overflow {
+	case OverflowDrop:
+		b.logger.Warn("dropped message for full subscriber queue", "topic", message.Topic, "order_id", message.Order.ID)
+		return nil
+	case OverflowError:
+		return fmt.Errorf("%w: %s", ErrQueueFull, message.Topic)
+	}
+	select {
+	case sub.queue <- message:
+		return nil
+	case <-sub.stop:
+		// Unsubscribed while waiting, the message is no longer wanted.
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Broker) PublishOrderCreated(ctx context.Context, order orders.Order) error {
+	return b.Publish(ctx, Message{Topic: TopicOrderCreated, Order: order})
+}
+
+func (b *Broker) PublishOrdersCreated(ctx context.Context, created []orders.Order) error {
+	for _, order := range created {
+		err := b.PublishOrderCreated(ctx, order)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Broker) PublishOrderStatusChanged(ctx context.Context, order orders.Order, previous orders.Status, changedAt time.Time) error {
+	return b.Publish(ctx, Message{Topic: TopicOrderStatusChanged, Order: order, PreviousStatus: previous, ChangedAt: changedAt})
+}
+
+// Close rejects new publishes and subscriptions and waits until the queued
+// messages are handled. If ctx ends first, the context of the running
+// handlers is cancelled and Close returns without waiting for them.
+func (b *Broker) Close(ctx context.Context) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.closed = true
+	var subs []*subscription
+	for _, topicSubs := range b.subscriptions {
+		subs = append(subs, topicSubs...)
+	}
+	b.subscriptions = nil
+	b.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		// Blocked publishes finish because the subscribers keep running.
+		b.publishing.Wait()
+		for _, sub := range subs {
+			sub.once.Do(func() { close(sub.stop) })
+		}
+		b.running.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return fmt.Errorf("failed to drain broker: %w", ctx.Err())
+	}
+}
+
+type BrokerConfig struct {
+	Logger *slog.Logger
+	// QueueSize is the capacity of the queue of every subscriber.
+	QueueSize int
+	Overflow  OverflowPolicy
+}
+
+func NewBroker(config BrokerConfig) *Broker {
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := &Broker{
+		logger:        config.Logger,
+		queueSize:     config.QueueSize,
+		overflow:      config.Overflow,
+		ctx:           ctx,
+		cancel:        cancel,
+		subscriptions: map[Topic][]*subscription{},
+	}
+	if broker.queueSize <= 0 {
+		broker.queueSize = 100
+	}
+	if !broker.overflow.IsValid() {
+		broker.overflow = OverflowBlock
+	}
+	return broker
+}
@@ -0,0 +1,243 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBroker(t *testing.T, queueSize int, overflow OverflowPolicy) *Broker {
+	broker := NewBroker(BrokerConfig{
+		Logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		QueueSize: queueSize,
+		Overflow:  overflow,
+	})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = broker.Close(ctx)
+	})
+	return broker
+}
+
+// recorder collects the messages of a subscription.
+type recorder struct {
+	mutex    sync.Mutex
+	messages []Message
+	received chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{received: make(chan struct{}, 100)}
+}
+
+func (r *recorder) handle(ctx context.Context, message Message) error {
+	r.mutex.Lock()
+	r.messages = append(r.messages, message)
+	r.mutex.Unlock()
+	r.received <- struct{}{}
+	return nil
+}
+
+func (r *recorder) wait(t *testing.T, count int) []Message {
+	for range count {
+		select {
+		case <-r.received:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.messages
+}
+
+// blockingHandler handles messages only after release is closed.
+func blockingHandler(release chan struct{}) Handler {
+	return func(ctx context.Context, message Message) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+}
+
+func TestBroker_DeliversMessagesOfSubscribedTopic(t *testing.T) {
+	broker := newTestBroker(t, 10, OverflowBlock)
+	created := newRecorder()
+	changed := newRecorder()
+	_, err := broker.Subscribe(TopicOrderCreated, created.handle)
+	require.Nil(t, err)
+	_, err = broker.Subscribe(TopicOrderStatusChanged, changed.handle)
+	require.Nil(t, err)
+
+	order := orders.Order{ID: 1, Status: orders.StatusPaid}
+	changedAt := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)
+	require.Nil(t, broker.PublishOrdersCreated(t.Context(), []orders.Order{{ID: 1}, {ID: 2}}))
+	require.Nil(t, broker.PublishOrderStatusChanged(t.Context(), order, orders.StatusCreated, changedAt))
+
+	assert.Equal(t, []Message{
+		{Topic: TopicOrderCreated, Order: orders.Order{ID: 1}},
+		{Topic: TopicOrderCreated, Order: orders.Order{ID: 2}},
+	}, created.wait(t, 2))
+	assert.Equal(t, []Message{
+		{Topic: TopicOrderStatusChanged, Order: order, PreviousStatus: orders.StatusCreated, ChangedAt: changedAt},
+	}, changed.wait(t, 1))
+}
+
+func TestBroker_DeliversToEverySubscriber(t *testing.T) {
+	broker := newTestBroker(t, 10, OverflowBlock)
+	first := newRecorder()
+	second := newRecorder()
+	_, err := broker.Subscribe(TopicOrderCreated, first.handle)
+	require.Nil(t, err)
+	_, err = broker.Subscribe(TopicOrderCreated, second.handle)
+	require.Nil(t, err)
+
+	require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: 1}))
+
+	assert.Len(t, first.wait(t, 1), 1)
+	assert.Len(t, second.wait(t, 1), 1)
+}
+
+func TestBroker_StopsDeliveringAfterUnsubscribe(t *testing.T) {
+	broker := newTestBroker(t, 10, OverflowBlock)
+	recorder := newRecorder()
+	unsubscribe, err := broker.Subscribe(TopicOrderCreated, recorder.handle)
+	require.Nil(t, err)
+	require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: 1}))
+	recorder.wait(t, 1)
+
+	unsubscribe()
+	require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: 2}))
+
+	require.Nil(t, broker.Close(t.Context()))
+	assert.Len(t, recorder.wait(t, 0), 1)
+}
+
+func TestBroker_DropsMessagesForFullQueue(t *testing.T) {
+	broker := newTestBroker(t, 1, OverflowDrop)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	handled := make(chan int, 10)
+	_, err := broker.Subscribe(TopicOrderCreated, func(ctx context.Context, message Message) error {
+		started <- struct{}{}
+		<-release
+		handled <- message.Order.ID
+		return nil
+	})
+	require.Nil(t, err)
+
+	// The first message is taken by the handler, the second fills the queue.
+	for id := 1; id <= 4; id++ {
+		require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: id}))
+		if id == 1 {
+			<-started
+		}
+	}
+	close(release)
+	require.Nil(t, broker.Close(t.Context()))
+
+	close(handled)
+	var ids []int
+	for id := range handled {
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []int{1, 2}, ids)
+}
+
+func TestBroker_ReturnsErrorForFullQueue(t *testing.T) {
+	broker := newTestBroker(t, 1, OverflowError)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	defer close(release)
+	_, err := broker.Subscribe(TopicOrderCreated, func(ctx context.Context, message Message) error {
+		started <- struct{}{}
+		return blockingHandler(release)(ctx, message)
+	})
+	require.Nil(t, err)
+	// Only the blocking subscriber is full, the other still gets the message.
+	recorder := newRecorder()
+	_, err = broker.Subscribe(TopicOrderCreated, recorder.handle)
+	require.Nil(t, err)
+
+	require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: 1}))
+	<-started
+	recorder.wait(t, 1)
+	require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: 2}))
+	recorder.wait(t, 1)
+	err = broker.PublishOrderCreated(t.Context(), orders.Order{ID: 3})
+
+	assert.True(t, errors.Is(err, ErrQueueFull), err)
+	assert.Len(t, recorder.wait(t, 1), 3)
+}
+
+func TestBroker_BlocksUntilContextIsDone(t *testing.T) {
+	broker := newTestBroker(t, 1, OverflowBlock)
+	release := make(chan struct{})
+	defer close(release)
+	_, err := broker.Subscribe(TopicOrderCreated, blockingHandler(release))
+	require.Nil(t, err)
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	for id := 1; err == nil; id++ {
+		err = broker.PublishOrderCreated(ctx, orders.Order{ID: id})
+	}
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestBroker_CloseDrainsQueuedMessages(t *testing.T) {
+	broker := newTestBroker(t, 10, OverflowBlock)
+	var handled []int
+	_, err := broker.Subscribe(TopicOrderCreated, func(ctx context.Context, message Message) error {
+		time.Sleep(time.Millisecond)
+		handled = append(handled, message.Order.ID)
+		return nil
+	})
+	require.Nil(t, err)
+	for id := 1; id <= 5; id++ {
+		require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: id}))
+	}
+
+	require.Nil(t, broker.Close(t.Context()))
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, handled)
+	err = broker.PublishOrderCreated(t.Context(), orders.Order{ID: 6})
+	assert.True(t, errors.Is(err, ErrBrokerClosed), err)
+	_, err = broker.Subscribe(TopicOrderCreated, newRecorder().handle)
+	assert.True(t, errors.Is(err, ErrBrokerClosed), err)
+}
+
+func TestBroker_CloseCancelsHandlersWhenDrainTimesOut(t *testing.T) {
+	broker := newTestBroker(t, 10, OverflowBlock)
+	cancelled := make(chan struct{})
+	_, err := broker.Subscribe(TopicOrderCreated, func(ctx context.Context, message Message) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	require.Nil(t, err)
+	require.Nil(t, broker.PublishOrderCreated(t.Context(), orders.Order{ID: 1}))
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	err = broker.Close(ctx)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+}
@@ -0,0 +1,203 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Topics are published to NATS subjects of the same name.
+var topics = []Topic{TopicOrderCreated, TopicOrderStatusChanged}
+
+// JetStreamMessagingSystem is an orders.MessagingSystem publishing to a
+// NATS JetStream stream. Messages are CloudEvents in the JSON event format
+// with the event id as message id, so the stream drops messages the Relay
+// publishes again within the duplicate window. A publish succeeds only after
+// the stream acknowledged it.
+type JetStreamMessagingSystem struct {
+	js     jetstream.JetStream
+	logger *slog.Logger
+	stream string
+}
+
+func (m JetStreamMessagingSystem) PublishOrderCreated(ctx context.Context, order orders.Order) error {
+	return m.publish(ctx, Message{Topic: TopicOrderCreated, Order: order})
+}
+
+// PublishOrdersCreated publishes the orders without waiting for each
+// acknowledgement in turn.
+func (m JetStreamMessagingSystem) PublishOrdersCreated(ctx context.Context, created []orders.Order) error {
+	futures := make([]jetstream.PubAckFuture, 0, len(created))
+	for _, order := range created {
+		message := Message{Topic: TopicOrderCreated, Order: order}
+		id, data, err := encodeMessage(message)
+		if err != nil {
+			return err
+		}
+		future, err := m.js.PublishAsync(string(message.Topic), data,
+			jetstream.WithMsgID(id), jetstream.WithExpectStream(m.stream))
+		if err != nil {
+			return err
+		}
+		futures = append(futures, future)
+	}
+	for _, future := range futures {
+		select {
+		case ack := <-future.Ok():
+			m.logAck(ack, future.Msg().Header.Get(jetstream.MsgIDHeader))
+		case err := <-future.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (m JetStreamMessagingSystem) PublishOrderStatusChanged(ctx context.Context, order orders.Order, previous orders.Status, changedAt time.Time) error {
+	return m.publish(ctx, Message{Topic: TopicOrderStatusChanged, Order: order, PreviousStatus: previous, ChangedAt: changedAt})
+}
+
+func (m JetStreamMessagingSystem) publish(ctx context.Context, message Message) error {
+	id, data, err := encodeMessage(message)
+	if err != nil {
+		return err
+	}
+	ack, err := m.js.Publish(ctx, string(message.Topic), data,
+		jetstream.WithMsgID(id), jetstream.WithExpectStream(m.stream))
+	if err != nil {
+		return err
+	}
+	m.logAck(ack, id)
+	return nil
+}
+
+func (m JetStreamMessagingSystem) logAck(ack *jetstream.PubAck, id string) {
+	if ack.Duplicate {
+		m.logger.Debug("stream dropped duplicate message", "stream", ack.Stream, "message_id", id)
+	}
+}
+
+type JetStreamConsumerConfig struct {
+	// Durable names the consumer. Consumers of the same name share the
+	// messages, and a restarted consumer continues where it left off.
+	Durable string
+	// Topics to consume, all of them if empty.
+	Topics []Topic
+	// AckWait is how long the stream waits for a message to be handled
+	// before delivering it again.
+	AckWait time.Duration
+	// MaxDeliver limits the deliveries of a message, zero is unlimited.
+	MaxDeliver int
+	// RetryDelay is how long a message that failed to be handled is held
+	// back before it is delivered again.
+	RetryDelay time.Duration
+}
+
+// Consume calls handle for the messages of a durable consumer until ctx is
+// cancelled. A message is acknowledged once handle succeeded, delivered
+// again after RetryDelay if it failed, and terminated if it can not be
+// decoded.
+func (m JetStreamMessagingSystem) Consume(ctx context.Context, config JetStreamConsumerConfig, handle Handler) error {
+	if config.Durable == "" {
+		return errors.New("durable consumer name must not be empty")
+	}
+	if len(config.Topics) == 0 {
+		config.Topics = topics
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+	var subjects []string
+	for _, topic := range config.Topics {
+		subjects = append(subjects, string(topic))
+	}
+	consumer, err := m.js.CreateOrUpdateConsumer(ctx, m.stream, jetstream.ConsumerConfig{
+		Durable:        config.Durable,
+		FilterSubjects: subjects,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        config.AckWait,
+		MaxDeliver:     config.MaxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s: %w", config.Durable, err)
+	}
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		m.handle(ctx, config, msg, handle)
+	})
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	// Unacknowledged messages are delivered again after AckWait.
+	consumeCtx.Stop()
+	<-consumeCtx.Closed()
+	return nil
+}
+
+func (m JetStreamMessagingSystem) handle(ctx context.Context, config JetStreamConsumerConfig, msg jetstream.Msg, handle Handler) {
+	logger := m.logger.With("consumer", config.Durable, "subject", msg.Subject())
+	message, err := decodeMessage(Topic(msg.Subject()), msg.Data())
+	if err != nil {
+		logger.Error("dropping malformed message", "error", err.Error())
+		err = msg.Term()
+		if err != nil {
+			logger.Error("failed to terminate message", "error", err.Error())
+		}
+		return
+	}
+	err = handle(ctx, message)
+	if err != nil {
+		logger.Error("failed to handle message", "order_id", message.Order.ID, "error", err.Error(),
+			"retry_in", config.RetryDelay.String())
+		err = msg.NakWithDelay(config.RetryDelay)
+	} else {
+		err = msg.Ack()
+	}
+	if err != nil {
+		logger.Error("failed to acknowledge message", "order_id", message.Order.ID, "error", err.Error())
+	}
+}
+
+type JetStreamConfig struct {
+	JetStream jetstream.JetStream
+	Logger    *slog.Logger
+	// Stream is created, or updated, to capture the topics.
+	Stream string
+	// DuplicateWindow is how long the stream remembers message ids.
+	DuplicateWindow time.Duration
+}
+
+// NewJetStreamMessagingSystem makes sure the stream exists.
+func NewJetStreamMessagingSystem(ctx context.Context, config JetStreamConfig) (JetStreamMessagingSystem, error) {
+	m := JetStreamMessagingSystem{
+		js:     config.JetStream,
+		logger: config.Logger,
+		stream: config.Stream,
+	}
+	if m.stream == "" {
+		m.stream = "ORDERS"
+	}
+	if config.DuplicateWindow <= 0 {
+		config.DuplicateWindow = 2 * time.Minute
+	}
+	var subjects []string
+	for _, topic := range topics {
+		subjects = append(subjects, string(topic))
+	}
+	_, err := m.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       m.stream,
+		Subjects:   subjects,
+		Storage:    jetstream.FileStorage,
+		Duplicates: config.DuplicateWindow,
+	})
+	if err != nil {
+		return m, fmt.Errorf("failed to create stream %s: %w", m.stream, err)
+	}
+	return m, nil
+}
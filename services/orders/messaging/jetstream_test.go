@@ -0,0 +1,219 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jetStreamFixture struct {
+	messaging JetStreamMessagingSystem
+	stream    jetstream.Stream
+}
+
+// setUpJetStreamTest starts an embedded NATS server that clients reach in
+// process, without listening on the network.
+func setUpJetStreamTest(t *testing.T) jetStreamFixture {
+	natsServer, err := server.NewServer(&server.Options{
+		JetStream:  true,
+		StoreDir:   t.TempDir(),
+		DontListen: true,
+	})
+	require.Nil(t, err)
+	natsServer.Start()
+	t.Cleanup(natsServer.Shutdown)
+	require.True(t, natsServer.ReadyForConnections(5*time.Second))
+
+	conn, err := nats.Connect("", nats.InProcessServer(natsServer))
+	require.Nil(t, err)
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.Nil(t, err)
+	messaging, err := NewJetStreamMessagingSystem(t.Context(), JetStreamConfig{
+		JetStream: js,
+		Logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	})
+	require.Nil(t, err)
+	stream, err := js.Stream(t.Context(), "ORDERS")
+	require.Nil(t, err)
+	return jetStreamFixture{
+		messaging: messaging,
+		stream:    stream,
+	}
+}
+
+func (f jetStreamFixture) messageCount(t *testing.T) uint64 {
+	info, err := f.stream.Info(t.Context())
+	require.Nil(t, err)
+	return info.State.Msgs
+}
+
+// consume runs a durable consumer until the test ends and returns the
+// channel of the messages it handled.
+func (f jetStreamFixture) consume(t *testing.T, config JetStreamConsumerConfig, handle Handler) <-chan Message {
+	messages := make(chan Message, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- f.messaging.Consume(ctx, config, func(ctx context.Context, message Message) error {
+			err := handle(ctx, message)
+			if err == nil {
+				messages <- message
+			}
+			return err
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.Nil(t, <-done)
+	})
+	return messages
+}
+
+func handleAll(ctx context.Context, message Message) error {
+	return nil
+}
+
+func receiveMessage(t *testing.T, messages <-chan Message) Message {
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+		return Message{}
+	}
+}
+
+var testOrder = orders.Order{
+	ID:         1,
+	TenantID:   "acme",
+	CustomerID: "alice",
+	Title:      "duck",
+	Status:     orders.StatusCreated,
+	Items:      []orders.Item{{SKU: "duck", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}},
+	Total:      orders.Money{Amount: 300, Currency: "EUR"},
+	CreatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Version:    1,
+}
+
+var testChangedAt = time.Date(2026, 1, 3, 4, 5, 6, 0, time.UTC)
+
+// statusChangedOrder returns the part of the order carried by status change
+// events.
+func statusChangedOrder(order orders.Order) orders.Order {
+	return orders.Order{
+		ID:         order.ID,
+		TenantID:   order.TenantID,
+		CustomerID: order.CustomerID,
+		Status:     order.Status,
+		Version:    order.Version,
+	}
+}
+
+func TestJetStream_DeliversPublishedMessages(t *testing.T) {
+	f := setUpJetStreamTest(t)
+	messages := f.consume(t, JetStreamConsumerConfig{Durable: "test"}, handleAll)
+	paid := testOrder
+	paid.Status = orders.StatusPaid
+	paid.Version = 2
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), paid, orders.StatusCreated, testChangedAt))
+
+	assert.Equal(t, Message{Topic: TopicOrderCreated, Order: testOrder}, receiveMessage(t, messages))
+	assert.Equal(t, Message{
+		Topic:          TopicOrderStatusChanged,
+		Order:          statusChangedOrder(paid),
+		PreviousStatus: orders.StatusCreated,
+		ChangedAt:      testChangedAt,
+	}, receiveMessage(t, messages))
+}
+
+func TestJetStream_DropsDuplicateMessages(t *testing.T) {
+	f := setUpJetStreamTest(t)
+	other := testOrder
+	other.ID = 2
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	require.Nil(t, f.messaging.PublishOrdersCreated(t.Context(), []orders.Order{testOrder, other}))
+	require.Nil(t, f.messaging.PublishOrdersCreated(t.Context(), []orders.Order{testOrder, other}))
+
+	assert.Equal(t, uint64(2), f.messageCount(t))
+}
+
+func TestJetStream_ConsumesOnlyTopicsOfConsumer(t *testing.T) {
+	f := setUpJetStreamTest(t)
+	messages := f.consume(t, JetStreamConsumerConfig{
+		Durable: "status",
+		Topics:  []Topic{TopicOrderStatusChanged},
+	}, handleAll)
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), testOrder, orders.StatusCreated, testChangedAt))
+
+	assert.Equal(t, TopicOrderStatusChanged, receiveMessage(t, messages).Topic)
+}
+
+func TestJetStream_RedeliversMessageAfterHandlerError(t *testing.T) {
+	f := setUpJetStreamTest(t)
+	var failures atomic.Int32
+	failures.Store(2)
+	messages := f.consume(t, JetStreamConsumerConfig{
+		Durable:    "test",
+		RetryDelay: 10 * time.Millisecond,
+	}, func(ctx context.Context, message Message) error {
+		if failures.Add(-1) >= 0 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+
+	assert.Equal(t, testOrder, receiveMessage(t, messages).Order)
+	assert.Equal(t, int32(-1), failures.Load())
+}
+
+func TestJetStream_DurableConsumerResumesAfterRestart(t *testing.T) {
+	f := setUpJetStreamTest(t)
+	config := JetStreamConsumerConfig{Durable: "test"}
+	ctx, cancel := context.WithCancel(t.Context())
+	received := make(chan Message, 10)
+	done := make(chan error)
+	go func() {
+		done <- f.messaging.Consume(ctx, config, func(ctx context.Context, message Message) error {
+			received <- message
+			return nil
+		})
+	}()
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	assert.Equal(t, 1, receiveMessage(t, received).Order.ID)
+	cancel()
+	require.Nil(t, <-done)
+
+	other := testOrder
+	other.ID = 2
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), other))
+	messages := f.consume(t, config, handleAll)
+
+	assert.Equal(t, 2, receiveMessage(t, messages).Order.ID)
+}
+
+func TestConsume_RejectsEmptyDurableName(t *testing.T) {
+	f := setUpJetStreamTest(t)
+
+	err := f.messaging.Consume(t.Context(), JetStreamConsumerConfig{}, handleAll)
+
+	assert.NotNil(t, err)
+}
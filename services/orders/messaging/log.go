@@ -0,0 +1,40 @@
+package messaging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+type LogMessagingSystem struct {
+	logger *slog.Logger
+}
+
+func (m LogMessagingSystem) PublishOrderCreated(ctx context.Context, order orders.Order) error {
+	m.logger.InfoContext(ctx, "order created", "id", order.ID, "title", order.Title,
+		"items", len(order.Items), "total", order.Total.Amount, "currency", order.Total.Currency)
+	return nil
+}
+
+func (m LogMessagingSystem) PublishOrdersCreated(ctx context.Context, created []orders.Order) error {
+	for _, order := range created {
+		err := m.PublishOrderCreated(ctx, order)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m LogMessagingSystem) PublishOrderStatusChanged(ctx context.Context, order orders.Order, previous orders.Status, changedAt time.Time) error {
+	m.logger.InfoContext(ctx, "order status changed", "id", order.ID, "from", previous, "to", order.Status, "changed_at", changedAt)
+	return nil
+}
+
+func NewLogMessagingSystem(logger *slog.Logger) LogMessagingSystem {
+	return LogMessagingSystem{
+		logger: logger,
+	}
+}
@@ -0,0 +1,65 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mrstecklo/micropet/services/orders/events"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+)
+
+// newEvent returns the CloudEvent of the message.
+func newEvent(message Message) (events.CloudEvent, error) {
+	switch message.Topic {
+	case TopicOrderCreated:
+		return events.NewOrderCreated(message.Order)
+	case TopicOrderStatusChanged:
+		return events.NewOrderStatusChanged(message.Order, message.PreviousStatus, message.ChangedAt)
+	default:
+		return events.CloudEvent{}, fmt.Errorf("unknown topic %q", message.Topic)
+	}
+}
+
+// encodeMessage returns the id of the event of the message and the event in
+// the JSON event format.
+func encodeMessage(message Message) (string, []byte, error) {
+	event, err := newEvent(message)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := json.Marshal(event)
+	return event.ID, data, err
+}
+
+// decodeMessage decodes a message sent to the topic.
+func decodeMessage(topic Topic, data []byte) (Message, error) {
+	legacyType := events.TypeOrderCreated
+	if topic == TopicOrderStatusChanged {
+		legacyType = events.TypeOrderStatusChanged
+	}
+	event, err := events.ParseJSONOrLegacy(data, legacyType)
+	if err != nil {
+		return Message{}, err
+	}
+	switch event.Type {
+	case events.TypeOrderCreated:
+		created, err := events.DecodeOrderCreated(event)
+		if err != nil {
+			return Message{}, err
+		}
+		return Message{Topic: TopicOrderCreated, Order: created.Order()}, nil
+	case events.TypeOrderStatusChanged:
+		changed, err := events.DecodeOrderStatusChanged(event)
+		if err != nil {
+			return Message{}, err
+		}
+		return Message{
+			Topic:          TopicOrderStatusChanged,
+			Order:          changed.Order(),
+			PreviousStatus: orders.Status(changed.PreviousStatus),
+			ChangedAt:      event.Time,
+		}, nil
+	default:
+		return Message{}, fmt.Errorf("unknown event type %q", event.Type)
+	}
+}
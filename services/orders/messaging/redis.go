@@ -0,0 +1,227 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisMessagingSystem is an orders.MessagingSystem appending to Redis
+// streams named after the topics. Every entry has a payload field with the
+// CloudEvent in the JSON event format and an id field with the event id, for
+// consumers reading the streams directly to detect messages the Relay
+// published again. Streams are trimmed to about MaxLen entries, so consumers
+// that fall further behind lose messages.
+type RedisMessagingSystem struct {
+	client redis.UniversalClient
+	logger *slog.Logger
+	maxLen int64
+}
+
+func (m RedisMessagingSystem) PublishOrderCreated(ctx context.Context, order orders.Order) error {
+	return m.publish(ctx, Message{Topic: TopicOrderCreated, Order: order})
+}
+
+// PublishOrdersCreated appends the orders in a single transaction.
+func (m RedisMessagingSystem) PublishOrdersCreated(ctx context.Context, created []orders.Order) error {
+	messages := make([]Message, 0, len(created))
+	for _, order := range created {
+		messages = append(messages, Message{Topic: TopicOrderCreated, Order: order})
+	}
+	return m.publish(ctx, messages...)
+}
+
+func (m RedisMessagingSystem) PublishOrderStatusChanged(ctx context.Context, order orders.Order, previous orders.Status, changedAt time.Time) error {
+	return m.publish(ctx, Message{Topic: TopicOrderStatusChanged, Order: order, PreviousStatus: previous, ChangedAt: changedAt})
+}
+
+func (m RedisMessagingSystem) publish(ctx context.Context, messages ...Message) error {
+	args := make([]*redis.XAddArgs, 0, len(messages))
+	for _, message := range messages {
+		id, data, err := encodeMessage(message)
+		if err != nil {
+			return err
+		}
+		args = append(args, &redis.XAddArgs{
+			Stream: string(message.Topic),
+			MaxLen: m.maxLen,
+			Approx: true,
+			Values: []any{"id", id, "payload", data},
+		})
+	}
+	if len(args) == 1 {
+		return m.client.XAdd(ctx, args[0]).Err()
+	}
+	_, err := m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, arg := range args {
+			pipe.XAdd(ctx, arg)
+		}
+		return nil
+	})
+	return err
+}
+
+type RedisConsumerConfig struct {
+	// Group names the consumer group. The consumers of a group share the
+	// messages, and a new group starts with the oldest retained message.
+	Group string
+	// Consumer names this consumer within the group. Messages it read but
+	// did not acknowledge stay pending under its name.
+	Consumer string
+	// Topics to consume, all of them if empty.
+	Topics []Topic
+	// BatchSize is the maximum number of messages read at once.
+	BatchSize int64
+	// Block is how long a read waits for new messages. Consume notices that
+	// ctx is cancelled only in between reads.
+	Block time.Duration
+	// MinIdle is how long a message stays pending before any consumer of
+	// the group claims it and delivers it again, which recovers messages
+	// of failed handlers and crashed consumers.
+	MinIdle time.Duration
+}
+
+// Consume calls handle for the messages of a consumer group until ctx is
+// cancelled. A message is acknowledged once handle succeeded, stays pending
+// if it failed, and is acknowledged and dropped if it can not be decoded.
+func (m RedisMessagingSystem) Consume(ctx context.Context, config RedisConsumerConfig, handle Handler) error {
+	if config.Group == "" || config.Consumer == "" {
+		return errors.New("consumer group and consumer name must not be empty")
+	}
+	if len(config.Topics) == 0 {
+		config.Topics = topics
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+	if config.Block <= 0 {
+		config.Block = time.Second
+	}
+	if config.MinIdle <= 0 {
+		config.MinIdle = time.Minute
+	}
+	streams := make([]string, 0, 2*len(config.Topics))
+	for _, topic := range config.Topics {
+		err := m.client.XGroupCreateMkStream(ctx, string(topic), config.Group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("failed to create consumer group %s of %s: %w", config.Group, topic, err)
+		}
+		streams = append(streams, string(topic))
+	}
+	for range config.Topics {
+		streams = append(streams, ">")
+	}
+
+	logger := m.logger.With("group", config.Group, "consumer", config.Consumer)
+	var claimedAt time.Time
+	for ctx.Err() == nil {
+		if time.Since(claimedAt) >= config.MinIdle/2 {
+			err := m.claim(ctx, config, logger, handle)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("failed to claim pending messages", "error", err.Error())
+			}
+			claimedAt = time.Now()
+		}
+		read, err := m.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    config.Group,
+			Consumer: config.Consumer,
+			Streams:  streams,
+			Count:    config.BatchSize,
+			Block:    config.Block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			logger.Error("failed to read messages", "error", err.Error(), "retry_in", config.Block.String())
+			select {
+			case <-ctx.Done():
+			case <-time.After(config.Block):
+			}
+			continue
+		}
+		for _, stream := range read {
+			for _, entry := range stream.Messages {
+				m.handle(ctx, config, logger, Topic(stream.Stream), entry, handle)
+			}
+		}
+	}
+	return nil
+}
+
+// claim takes over the messages pending longer than MinIdle and handles
+// them.
+func (m RedisMessagingSystem) claim(ctx context.Context, config RedisConsumerConfig, logger *slog.Logger, handle Handler) error {
+	for _, topic := range config.Topics {
+		for start := "0-0"; ; {
+			entries, next, err := m.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   string(topic),
+				Group:    config.Group,
+				Consumer: config.Consumer,
+				MinIdle:  config.MinIdle,
+				Start:    start,
+				Count:    config.BatchSize,
+			}).Result()
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				logger.Info("claimed pending message", "stream", topic, "entry_id", entry.ID)
+				m.handle(ctx, config, logger, topic, entry, handle)
+			}
+			if next == "0-0" {
+				break
+			}
+			start = next
+		}
+	}
+	return nil
+}
+
+func (m RedisMessagingSystem) handle(ctx context.Context, config RedisConsumerConfig, logger *slog.Logger, topic Topic, entry redis.XMessage, handle Handler) {
+	logger = logger.With("stream", topic, "entry_id", entry.ID)
+	payload, _ := entry.Values["payload"].(string)
+	message, err := decodeMessage(topic, []byte(payload))
+	if err != nil {
+		logger.Error("dropping malformed message", "error", err.Error())
+	} else {
+		err = handle(ctx, message)
+		if err != nil {
+			logger.Error("failed to handle message", "order_id", message.Order.ID, "error", err.Error(),
+				"retry_in", config.MinIdle.String())
+			return
+		}
+	}
+	err = m.client.XAck(ctx, string(topic), config.Group, entry.ID).Err()
+	if err != nil {
+		logger.Error("failed to acknowledge message", "error", err.Error())
+	}
+}
+
+type RedisConfig struct {
+	Client redis.UniversalClient
+	Logger *slog.Logger
+	// MaxLen is the approximate number of entries kept in every stream.
+	MaxLen int64
+}
+
+func NewRedisMessagingSystem(config RedisConfig) RedisMessagingSystem {
+	m := RedisMessagingSystem{
+		client: config.Client,
+		logger: config.Logger,
+		maxLen: config.MaxLen,
+	}
+	if m.maxLen <= 0 {
+		m.maxLen = 100_000
+	}
+	return m
+}
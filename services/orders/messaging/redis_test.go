@@ -0,0 +1,245 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type redisFixture struct {
+	messaging RedisMessagingSystem
+	client    *redis.Client
+}
+
+func setUpRedisTest(t *testing.T, maxLen int64) redisFixture {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return redisFixture{
+		messaging: NewRedisMessagingSystem(RedisConfig{
+			Client: client,
+			Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+			MaxLen: maxLen,
+		}),
+		client: client,
+	}
+}
+
+func testRedisConsumerConfig() RedisConsumerConfig {
+	return RedisConsumerConfig{
+		Group:    "test",
+		Consumer: "first",
+		Block:    10 * time.Millisecond,
+		MinIdle:  50 * time.Millisecond,
+	}
+}
+
+// consume runs a consumer until the test ends and returns the channel of
+// the messages it handled.
+func (f redisFixture) consume(t *testing.T, config RedisConsumerConfig, handle Handler) <-chan Message {
+	messages := make(chan Message, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- f.messaging.Consume(ctx, config, func(ctx context.Context, message Message) error {
+			err := handle(ctx, message)
+			if err == nil {
+				messages <- message
+			}
+			return err
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.Nil(t, <-done)
+	})
+	return messages
+}
+
+func TestRedis_DeliversPublishedMessages(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	messages := f.consume(t, testRedisConsumerConfig(), handleAll)
+	paid := testOrder
+	paid.Status = orders.StatusPaid
+	paid.Version = 2
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), paid, orders.StatusCreated, testChangedAt))
+
+	received := map[Topic]Message{}
+	for range 2 {
+		message := receiveMessage(t, messages)
+		received[message.Topic] = message
+	}
+	assert.Equal(t, map[Topic]Message{
+		TopicOrderCreated: {Topic: TopicOrderCreated, Order: testOrder},
+		TopicOrderStatusChanged: {
+			Topic:          TopicOrderStatusChanged,
+			Order:          statusChangedOrder(paid),
+			PreviousStatus: orders.StatusCreated,
+			ChangedAt:      testChangedAt,
+		},
+	}, received)
+}
+
+func TestRedis_PublishesMessageIDs(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	other := testOrder
+	other.ID = 2
+
+	require.Nil(t, f.messaging.PublishOrdersCreated(t.Context(), []orders.Order{testOrder, other}))
+
+	entries, err := f.client.XRange(t.Context(), string(TopicOrderCreated), "-", "+").Result()
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "acme:1:1:created", entries[0].Values["id"])
+	assert.Equal(t, "acme:2:1:created", entries[1].Values["id"])
+}
+
+func TestRedis_PublishesSameEventForRepublishedChange(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	paid := testOrder
+	paid.Status = orders.StatusPaid
+	paid.Version = 2
+
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), paid, orders.StatusCreated, testChangedAt))
+	time.Sleep(10 * time.Millisecond)
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), paid, orders.StatusCreated, testChangedAt))
+
+	entries, err := f.client.XRange(t.Context(), string(TopicOrderStatusChanged), "-", "+").Result()
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, entries[0].Values, entries[1].Values)
+	assert.Contains(t, entries[0].Values["payload"], `"time":"2026-01-03T04:05:06Z"`)
+}
+
+func TestRedis_CapsStreamLength(t *testing.T) {
+	f := setUpRedisTest(t, 3)
+
+	for id := 1; id <= 5; id++ {
+		order := testOrder
+		order.ID = id
+		require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), order))
+	}
+
+	length, err := f.client.XLen(t.Context(), string(TopicOrderCreated)).Result()
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), length)
+}
+
+func TestRedis_ConsumesOnlyTopicsOfConsumer(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	config := testRedisConsumerConfig()
+	config.Topics = []Topic{TopicOrderStatusChanged}
+	messages := f.consume(t, config, handleAll)
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	require.Nil(t, f.messaging.PublishOrderStatusChanged(t.Context(), testOrder, orders.StatusCreated, testChangedAt))
+
+	assert.Equal(t, TopicOrderStatusChanged, receiveMessage(t, messages).Topic)
+}
+
+func TestRedis_AcknowledgesHandledMessages(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	messages := f.consume(t, testRedisConsumerConfig(), handleAll)
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	receiveMessage(t, messages)
+
+	assert.Eventually(t, func() bool {
+		pending, err := f.client.XPending(t.Context(), string(TopicOrderCreated), "test").Result()
+		return err == nil && pending.Count == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedis_RedeliversMessageAfterHandlerError(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	var failures atomic.Int32
+	failures.Store(1)
+	messages := f.consume(t, testRedisConsumerConfig(), func(ctx context.Context, message Message) error {
+		if failures.Add(-1) >= 0 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+
+	assert.Equal(t, testOrder, receiveMessage(t, messages).Order)
+}
+
+func TestRedis_ClaimsMessagesOfCrashedConsumer(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	config := testRedisConsumerConfig()
+	require.Nil(t, f.client.XGroupCreateMkStream(t.Context(), string(TopicOrderCreated), config.Group, "0").Err())
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+	// The crashed consumer read the message without acknowledging it.
+	read, err := f.client.XReadGroup(t.Context(), &redis.XReadGroupArgs{
+		Group:    config.Group,
+		Consumer: "crashed",
+		Streams:  []string{string(TopicOrderCreated), ">"},
+	}).Result()
+	require.Nil(t, err)
+	require.Len(t, read[0].Messages, 1)
+
+	messages := f.consume(t, config, handleAll)
+
+	assert.Equal(t, testOrder, receiveMessage(t, messages).Order)
+}
+
+func TestRedis_DropsMalformedMessages(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	messages := f.consume(t, testRedisConsumerConfig(), handleAll)
+
+	require.Nil(t, f.client.XAdd(t.Context(), &redis.XAddArgs{
+		Stream: string(TopicOrderCreated),
+		Values: []any{"payload", "not json"},
+	}).Err())
+	require.Nil(t, f.messaging.PublishOrderCreated(t.Context(), testOrder))
+
+	assert.Equal(t, testOrder, receiveMessage(t, messages).Order)
+	assert.Eventually(t, func() bool {
+		pending, err := f.client.XPending(t.Context(), string(TopicOrderCreated), "test").Result()
+		return err == nil && pending.Count == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedis_DecodesMessagesPublishedBeforeCloudEvents(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+	messages := f.consume(t, testRedisConsumerConfig(), handleAll)
+
+	require.Nil(t, f.client.XAdd(t.Context(), &redis.XAddArgs{
+		Stream: string(TopicOrderCreated),
+		Values: []any{"id", "acme:1:1", "payload", `{"order": {
+			"id": 1,
+			"tenant_id": "acme",
+			"customer_id": "alice",
+			"title": "duck",
+			"status": "created",
+			"items": [{"sku": "duck", "quantity": 2, "unit_price": {"amount": 150, "currency": "EUR"}}],
+			"total": {"amount": 300, "currency": "EUR"},
+			"created_at": "2026-01-02T03:04:05Z",
+			"version": 1
+		}}`},
+	}).Err())
+
+	assert.Equal(t, Message{Topic: TopicOrderCreated, Order: testOrder}, receiveMessage(t, messages))
+}
+
+func TestRedisConsume_RejectsEmptyNames(t *testing.T) {
+	f := setUpRedisTest(t, 0)
+
+	err := f.messaging.Consume(t.Context(), RedisConsumerConfig{Group: "test"}, handleAll)
+
+	assert.NotNil(t, err)
+}
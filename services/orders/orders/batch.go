@@ -0,0 +1,94 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const MaxBatchSize = 1000
+
+var ErrInvalidBatch = errors.New("invalid batch")
+
+type BatchMode string
+
+const (
+	// BatchAtomic creates either all orders of a batch or none of them.
+	BatchAtomic BatchMode = "atomic"
+	// BatchPartial creates the valid orders of a batch and reports the
+	// invalid ones.
+	BatchPartial BatchMode = "partial"
+)
+
+func (m BatchMode) IsValid() bool {
+	return m == BatchAtomic || m == BatchPartial
+}
+
+type OrderRequest struct {
+	Title string
+	Items []Item
+}
+
+// BatchResult is the outcome for one order of a batch: the created order, or
+// the error that kept it from being created.
+type BatchResult struct {
+	Order Order
+	Err   error
+}
+
+// CreateOrders creates the requested orders in a single Database call. In
+// BatchAtomic mode an invalid order fails the whole batch with a
+// ValidationError whose fields are prefixed with the index of the order, e.g.
+// "[3].title". In BatchPartial mode the results report the invalid orders.
+func (e Engine) CreateOrders(ctx context.Context, principal Principal, requests []OrderRequest, mode BatchMode) ([]BatchResult, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidBatch, mode)
+	}
+	if len(requests) == 0 || len(requests) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: must contain between 1 and %d orders", ErrInvalidBatch, MaxBatchSize)
+	}
+	err := principal.validate()
+	if err != nil {
+		return nil, err
+	}
+	if principal.CustomerID == "" {
+		return nil, ErrUnauthenticated
+	}
+	results := make([]BatchResult, len(requests))
+	var valid []Order
+	var fields []FieldError
+	for idx, request := range requests {
+		order, err := e.newOrder(principal, request.Title, request.Items)
+		if err != nil {
+			var validationError ValidationError
+			if !errors.As(err, &validationError) {
+				return nil, err
+			}
+			for _, field := range validationError.Fields {
+				field.Field = fmt.Sprintf("[%d].%s", idx, field.Field)
+				fields = append(fields, field)
+			}
+			results[idx].Err = err
+			continue
+		}
+		results[idx].Order = order
+		valid = append(valid, order)
+	}
+	if mode == BatchAtomic && fields != nil {
+		return nil, ValidationError{Fields: fields}
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+	ids, err := e.database.CreateOrders(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range results {
+		if results[idx].Err == nil {
+			results[idx].Order.ID = ids[0]
+			ids = ids[1:]
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,33 @@
+package orders
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventOrderCreated       EventType = "order_created"
+	EventOrderStatusChanged EventType = "order_status_changed"
+)
+
+type Event struct {
+	ID             int64
+	Type           EventType
+	Order          Order
+	PreviousStatus Status
+	// CreatedAt is when the event was recorded, together with its change.
+	CreatedAt time.Time
+}
+
+// Outbox holds events recorded by Database together with the changes that
+// caused them, until the Relay delivers them to the MessagingSystem.
+type Outbox interface {
+	// PendingEvents returns up to limit undelivered events, oldest first.
+	PendingEvents(ctx context.Context, limit int) ([]Event, error)
+	MarkEventDelivered(ctx context.Context, id int64) error
+	// PruneDeliveredEvents deletes the events delivered before the time and
+	// returns their number. Pending events are kept.
+	PruneDeliveredEvents(ctx context.Context, before time.Time) (int64, error)
+}
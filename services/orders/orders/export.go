@@ -0,0 +1,20 @@
+package orders
+
+import "context"
+
+// ExportOrders calls visit with every order matching the filter in id order
+// and stops at the first error. Customers only export their own orders.
+func (e Engine) ExportOrders(ctx context.Context, principal Principal, filter ListFilter, visit func(Order) error) error {
+	err := principal.validate()
+	if err != nil {
+		return err
+	}
+	err = filter.validate()
+	if err != nil {
+		return err
+	}
+	if !principal.Admin {
+		filter.CustomerID = principal.CustomerID
+	}
+	return e.database.ExportOrders(ctx, principal.TenantID, filter, visit)
+}
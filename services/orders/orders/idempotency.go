@@ -0,0 +1,37 @@
+package orders
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const maxIdempotencyKeyLength = 255
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was used with a different request")
+)
+
+// IdempotencyKey identifies a client request. Fingerprint is a digest of the
+// request content used to tell a retry from a reuse of the key.
+type IdempotencyKey struct {
+	Key         string
+	Fingerprint string
+	TTL         time.Duration
+}
+
+func fingerprint(customer string, title string, items []Item) (string, error) {
+	data, err := json.Marshal(struct {
+		Customer string
+		Title    string
+		Items    []Item
+	}{customer, title, items})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
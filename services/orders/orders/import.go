@@ -0,0 +1,107 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// importBatchSize is the number of valid records passed to
+// Database.ImportOrders at once.
+const importBatchSize = 1000
+
+// ImportRecord is one order read from an import file. Err is set if the
+// record could not be parsed.
+type ImportRecord struct {
+	Line       int
+	CustomerID string
+	Title      string
+	Items      []Item
+	Err        error
+}
+
+// ImportSource yields the records of an import file. Next returns io.EOF
+// after the last record and any other error if the file can not be read any
+// further.
+type ImportSource interface {
+	Next() (ImportRecord, error)
+}
+
+// ImportError tells why the record on Line was not imported.
+type ImportError struct {
+	Line int
+	Err  error
+}
+
+type ImportReport struct {
+	Records  int
+	Imported int
+	Errors   []ImportError
+}
+
+// ImportOrders validates every record with the rules of CreateOrder and
+// stores the valid ones in batches, so a failing import may have stored some
+// of them already. A dry run only validates. Only admins may import orders,
+// on behalf of the customer of each record.
+func (e Engine) ImportOrders(ctx context.Context, principal Principal, source ImportSource, dryRun bool) (ImportReport, error) {
+	var report ImportReport
+	err := principal.validate()
+	if err != nil {
+		return report, err
+	}
+	if !principal.Admin {
+		return report, ErrForbidden
+	}
+	var batch []Order
+	store := func() error {
+		if dryRun || len(batch) == 0 {
+			return nil
+		}
+		err := e.database.ImportOrders(ctx, batch)
+		if err != nil {
+			return err
+		}
+		report.Imported += len(batch)
+		batch = nil
+		return nil
+	}
+	for {
+		record, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Records += 1
+		order, err := e.importOrder(principal.TenantID, record)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportError{Line: record.Line, Err: err})
+			continue
+		}
+		batch = append(batch, order)
+		if len(batch) == importBatchSize {
+			err = store()
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, store()
+}
+
+func (e Engine) importOrder(tenant string, record ImportRecord) (Order, error) {
+	if record.Err != nil {
+		return Order{}, record.Err
+	}
+	order, err := e.buildOrder(tenant, record.CustomerID, record.Title, record.Items)
+	if record.CustomerID == "" {
+		fields := []FieldError{{"customer_id", CodeRequired, "must not be empty"}}
+		var validationErr ValidationError
+		if errors.As(err, &validationErr) {
+			fields = append(fields, validationErr.Fields...)
+		}
+		return Order{}, ValidationError{Fields: fields}
+	}
+	return order, err
+}
@@ -0,0 +1,59 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidItem = errors.New("invalid order item")
+
+type Item struct {
+	SKU       string
+	Quantity  int
+	UnitPrice Money
+}
+
+func (i Item) Total() (Money, error) {
+	return i.UnitPrice.Multiply(int64(i.Quantity))
+}
+
+func (i Item) Validate() error {
+	if i.SKU == "" {
+		return fmt.Errorf("%w: empty SKU", ErrInvalidItem)
+	}
+	if i.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidItem)
+	}
+	if i.UnitPrice.IsNegative() {
+		return fmt.Errorf("%w: negative unit price", ErrInvalidItem)
+	}
+	err := ValidateCurrency(i.UnitPrice.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidItem, err)
+	}
+	return nil
+}
+
+// CalculateTotal validates items and returns the sum of their totals. All
+// items must be priced in the same currency. An empty order totals to the
+// zero Money.
+func CalculateTotal(items []Item) (Money, error) {
+	if len(items) == 0 {
+		return Money{}, nil
+	}
+	total := Money{Currency: items[0].UnitPrice.Currency}
+	for idx, item := range items {
+		err := item.Validate()
+		if err != nil {
+			return Money{}, fmt.Errorf("item %d: %w", idx, err)
+		}
+		itemTotal, err := item.Total()
+		if err == nil {
+			total, err = total.Add(itemTotal)
+		}
+		if err != nil {
+			return Money{}, fmt.Errorf("item %d: %w: %w", idx, ErrInvalidItem, err)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,136 @@
+package orders
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidQuery  = errors.New("invalid list query")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+type SortOrder string
+
+const (
+	SortByIDAsc         SortOrder = "id"
+	SortByIDDesc        SortOrder = "-id"
+	SortByCreatedAtAsc  SortOrder = "created_at"
+	SortByCreatedAtDesc SortOrder = "-created_at"
+)
+
+func (s SortOrder) IsValid() bool {
+	switch s {
+	case SortByIDAsc, SortByIDDesc, SortByCreatedAtAsc, SortByCreatedAtDesc:
+		return true
+	}
+	return false
+}
+
+// ListFilter selects orders. Zero fields do not filter. CreatedFrom is
+// inclusive and CreatedTo is exclusive.
+type ListFilter struct {
+	Statuses      []Status
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	TitleContains string
+	CustomerID    string
+}
+
+func (f ListFilter) validate() error {
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, status)
+		}
+	}
+	return nil
+}
+
+// Cursor is the position of the last order of a page in the sort order.
+type Cursor struct {
+	Sort      SortOrder `json:"s"`
+	CreatedAt time.Time `json:"c"`
+	ID        int       `json:"i"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (Cursor, error) {
+	var cursor Cursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || !cursor.Sort.IsValid() {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// ListQuery is what Database.ListOrders receives: at most Limit orders
+// matching Filter that follow After in Sort order.
+type ListQuery struct {
+	Filter ListFilter
+	Sort   SortOrder
+	After  *Cursor
+	Limit  int
+}
+
+type ListRequest struct {
+	Filter ListFilter
+	Sort   SortOrder
+	Cursor string
+	Limit  int
+}
+
+type OrderPage struct {
+	Orders     []Order
+	NextCursor string
+}
+
+func (r ListRequest) query() (ListQuery, error) {
+	query := ListQuery{
+		Filter: r.Filter,
+		Sort:   r.Sort,
+		Limit:  r.Limit,
+	}
+	if query.Sort == "" {
+		query.Sort = SortByCreatedAtDesc
+	}
+	if !query.Sort.IsValid() {
+		return query, fmt.Errorf("%w: unknown sort order %q", ErrInvalidQuery, r.Sort)
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return query, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	err := r.Filter.validate()
+	if err != nil {
+		return query, err
+	}
+	if r.Cursor != "" {
+		cursor, err := DecodeCursor(r.Cursor)
+		if err != nil {
+			return query, err
+		}
+		if cursor.Sort != query.Sort {
+			return query, ErrInvalidCursor
+		}
+		query.After = &cursor
+	}
+	return query, nil
+}
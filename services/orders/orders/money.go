@@ -0,0 +1,58 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrInvalidCurrency  = errors.New("invalid currency code")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrAmountOverflow   = errors.New("amount overflow")
+)
+
+// Money is a fixed-point amount in the minor units of Currency, which is an
+// ISO 4217 code, e.g. Money{Amount: 1050, Currency: "EUR"} is 10.50 EUR.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func ValidateCurrency(code string) error {
+	if len(code) != 3 {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+		}
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Multiply(factor int64) (Money, error) {
+	if m.Amount == 0 || factor == 0 {
+		return Money{Amount: 0, Currency: m.Currency}, nil
+	}
+	result := m.Amount * factor
+	if result/factor != m.Amount || (m.Amount == math.MinInt64 && factor == -1) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: result, Currency: m.Currency}, nil
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
@@ -1,47 +1,263 @@
 package orders
 
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+)
+
+// ErrNotFound is returned by Database implementations when the requested
+// order does not exist.
+var ErrNotFound = errors.New("order not found")
+
 type Order struct {
-	ID    int
-	Title string
+	ID         int
+	TenantID   string
+	CustomerID string
+	Title      string
+	Status     Status
+	Items      []Item
+	Total      Money
+	CreatedAt  time.Time
+	Version    int
 }
 
+// Database stores orders. Creation and status changes are committed
+// atomically with the corresponding Event in the Outbox. Orders are looked up
+// and modified within their tenant only: an order of another tenant is
+// reported as ErrNotFound.
 type Database interface {
-	CreateOrder(title string) (int, error)
-	GetOrder(id int) (Order, error)
+	// CreateOrder stores the order and records EventOrderCreated.
+	CreateOrder(ctx context.Context, order Order) (int, error)
+	// CreateOrders stores orders of a single tenant like CreateOrder in one
+	// transaction and returns their ids in the same order.
+	CreateOrders(ctx context.Context, orders []Order) ([]int, error)
+	// ImportOrders stores orders of a single tenant like CreateOrders, using
+	// the fastest way to load many orders at once.
+	ImportOrders(ctx context.Context, orders []Order) error
+	// CreateOrderWithKey stores the order like CreateOrder unless an
+	// unexpired key with the same Key exists for the tenant of the order. In
+	// that case it returns the id
+	// of the order created with the key and false, or ErrIdempotencyKeyReused
+	// if the fingerprints differ.
+	CreateOrderWithKey(ctx context.Context, order Order, key IdempotencyKey) (int, bool, error)
+	// DeleteExpiredIdempotencyKeys deletes the expired keys of all tenants
+	// and returns their number. CreateOrderWithKey ignores expired keys, so
+	// this only reclaims their space.
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	GetOrder(ctx context.Context, tenant string, id int) (Order, error)
+	ListOrders(ctx context.Context, tenant string, query ListQuery) ([]Order, error)
+	// ExportOrders calls visit with every order matching the filter in id
+	// order without loading all of them at once. It returns the first error
+	// of visit.
+	ExportOrders(ctx context.Context, tenant string, filter ListFilter, visit func(Order) error) error
+	// SearchOrders returns the page of orders matching query, ordered by
+	// decreasing relevance and then by decreasing id.
+	SearchOrders(ctx context.Context, tenant string, query SearchQuery, page Page) ([]Order, error)
+	// UpdateOrderStatus sets the status and version of the order to
+	// order.Status and order.Version if its current status is previous and
+	// its current version is version, and records EventOrderStatusChanged.
+	// It returns TransitionError if the current status differs and
+	// ErrVersionMismatch if only the current version differs.
+	UpdateOrderStatus(ctx context.Context, order Order, previous Status, version int) error
+	// UpdateOrder replaces the title, items, total and version of the order
+	// if its current version is version. It returns ErrVersionMismatch if the
+	// current version differs.
+	UpdateOrder(ctx context.Context, order Order, version int) error
 }
 
 type MessagingSystem interface {
-	PublishOrderCreated(order Order) error
+	PublishOrderCreated(ctx context.Context, order Order) error
+	// PublishOrdersCreated publishes the creation of several orders at once.
+	// It fails as a whole, so the orders are published again on retry.
+	PublishOrdersCreated(ctx context.Context, orders []Order) error
+	// PublishOrderStatusChanged publishes the status change of the order
+	// that happened at changedAt. Publishing the same change again produces
+	// the same message.
+	PublishOrderStatusChanged(ctx context.Context, order Order, previous Status, changedAt time.Time) error
 }
 
 type Engine struct {
-	database  Database
-	messaging MessagingSystem
+	database       Database
+	idempotencyTTL time.Duration
+	now            func() time.Time
+	validator      validator
+}
+
+func (e Engine) newOrder(principal Principal, title string, items []Item) (Order, error) {
+	err := principal.validate()
+	if err != nil {
+		return Order{}, err
+	}
+	if principal.CustomerID == "" {
+		return Order{}, ErrUnauthenticated
+	}
+	return e.buildOrder(principal.TenantID, principal.CustomerID, title, items)
+}
+
+func (e Engine) buildOrder(tenant string, customer string, title string, items []Item) (Order, error) {
+	err := e.validator.validate(&title, &items)
+	if err != nil {
+		return Order{}, err
+	}
+	total, err := CalculateTotal(items)
+	if err != nil {
+		return Order{}, err
+	}
+	return Order{
+		TenantID:   tenant,
+		CustomerID: customer,
+		Title:      title,
+		Status:     StatusCreated,
+		Items:      items,
+		Total:      total,
+		CreatedAt:  e.now().UTC().Truncate(time.Microsecond),
+		Version:    1,
+	}, nil
 }
 
-func (e Engine) CreateOrder(title string) (int, error) {
-	id, err := e.database.CreateOrder(title)
+func (e Engine) CreateOrder(ctx context.Context, principal Principal, title string, items []Item) (Order, error) {
+	order, err := e.newOrder(principal, title, items)
 	if err != nil {
-		return 0, err
+		return Order{}, err
 	}
-	err = e.messaging.PublishOrderCreated(Order{
-		ID:    id,
-		Title: title,
+	id, err := e.database.CreateOrder(ctx, order)
+	if err != nil {
+		return Order{}, err
+	}
+	order.ID = id
+	return order, nil
+}
+
+// CreateOrderIdempotent creates the order like CreateOrder. A retry with the
+// same key and request returns the order created by the first call and false.
+func (e Engine) CreateOrderIdempotent(ctx context.Context, principal Principal, key string, title string, items []Item) (Order, bool, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return Order{}, false, ErrInvalidIdempotencyKey
+	}
+	order, err := e.newOrder(principal, title, items)
+	if err != nil {
+		return Order{}, false, err
+	}
+	digest, err := fingerprint(principal.CustomerID, title, items)
+	if err != nil {
+		return Order{}, false, err
+	}
+	id, created, err := e.database.CreateOrderWithKey(ctx, order, IdempotencyKey{
+		Key:         key,
+		Fingerprint: digest,
+		TTL:         e.idempotencyTTL,
 	})
 	if err != nil {
-		return 0, err
+		return Order{}, false, err
+	}
+	if !created {
+		order, err = e.GetOrder(ctx, principal, id)
+		return order, false, err
 	}
-	return id, err
+	order.ID = id
+	return order, true, nil
+}
+
+// GetOrder returns the order if the principal may access it. Orders of other
+// customers are reported as ErrNotFound, so their ids can not be probed.
+func (e Engine) GetOrder(ctx context.Context, principal Principal, id int) (Order, error) {
+	err := principal.validate()
+	if err != nil {
+		return Order{}, err
+	}
+	order, err := e.database.GetOrder(ctx, principal.TenantID, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if !principal.canAccess(order) {
+		return Order{}, ErrNotFound
+	}
+	return order, nil
+}
+
+// ListOrders returns a page of orders. Pass OrderPage.NextCursor of a page as
+// ListRequest.Cursor to get the next one; it is empty on the last page.
+// Customers only get their own orders.
+func (e Engine) ListOrders(ctx context.Context, principal Principal, request ListRequest) (OrderPage, error) {
+	err := principal.validate()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	query, err := request.query()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	if !principal.Admin {
+		query.Filter.CustomerID = principal.CustomerID
+	}
+	limit := query.Limit
+	query.Limit += 1
+	found, err := e.database.ListOrders(ctx, principal.TenantID, query)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	page := OrderPage{Orders: found}
+	if len(found) > limit {
+		page.Orders = found[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = Cursor{Sort: query.Sort, CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
+
+func (e Engine) ChangeOrderStatus(ctx context.Context, principal Principal, id int, status Status) (Order, error) {
+	order, err := e.GetOrder(ctx, principal, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if !CanTransition(order.Status, status) {
+		return Order{}, TransitionError{From: order.Status, To: status}
+	}
+	if !principal.Admin && status != StatusCancelled {
+		return Order{}, ErrForbidden
+	}
+	previous, version := order.Status, order.Version
+	order.Status = status
+	order.Version += 1
+	err = e.database.UpdateOrderStatus(ctx, order, previous, version)
+	if err != nil {
+		return Order{}, err
+	}
+	return order, nil
 }
 
 type Config struct {
-	Database  Database
-	Messaging MessagingSystem
+	Database       Database
+	IdempotencyTTL time.Duration
+	Now            func() time.Time
+	// MaxTitleLength limits the number of characters in a title,
+	// DefaultMaxTitleLength if zero.
+	MaxTitleLength int
+	// TitlePattern restricts the characters allowed in a title. Titles may
+	// never contain control characters.
+	TitlePattern *regexp.Regexp
 }
 
 func NewEngine(config Config) Engine {
-	return Engine{
-		database:  config.Database,
-		messaging: config.Messaging,
+	engine := Engine{
+		database:       config.Database,
+		idempotencyTTL: config.IdempotencyTTL,
+		now:            config.Now,
+		validator: validator{
+			maxTitleLength: config.MaxTitleLength,
+			titlePattern:   config.TitlePattern,
+		},
+	}
+	if engine.idempotencyTTL <= 0 {
+		engine.idempotencyTTL = 24 * time.Hour
+	}
+	if engine.now == nil {
+		engine.now = time.Now
+	}
+	if engine.validator.maxTitleLength <= 0 {
+		engine.validator.maxTitleLength = DefaultMaxTitleLength
 	}
+	return engine
 }
@@ -0,0 +1,34 @@
+package orders
+
+import "errors"
+
+var (
+	// ErrUnauthenticated is returned by Engine when a call has no customer.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned by Engine operations reserved for admins.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Principal is the authenticated caller of an Engine method. Customers can
+// only see and change the orders they own, admins all orders of their tenant.
+// Only admins move orders through fulfilment, customers can at most cancel.
+type Principal struct {
+	TenantID   string
+	CustomerID string
+	Admin      bool
+}
+
+func (p Principal) validate() error {
+	err := ValidateTenant(p.TenantID)
+	if err != nil {
+		return err
+	}
+	if p.CustomerID == "" && !p.Admin {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+func (p Principal) canAccess(order Order) bool {
+	return p.Admin || order.CustomerID == p.CustomerID
+}
@@ -0,0 +1,171 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Relay delivers events from the Outbox to the MessagingSystem. An event is
+// marked delivered only after it was published, so every event is published
+// at least once. Delivered events are kept for the retention period.
+type Relay struct {
+	outbox        Outbox
+	messaging     MessagingSystem
+	logger        *slog.Logger
+	batchSize     int
+	pollInterval  time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	retention     time.Duration
+	pruneInterval time.Duration
+}
+
+// Drain publishes pending events in order until the outbox is empty or an
+// event fails to be published. It returns the number of delivered events.
+func (r Relay) Drain(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		events, err := r.outbox.PendingEvents(ctx, r.batchSize)
+		if err != nil {
+			return delivered, err
+		}
+		for pending := events; len(pending) > 0; {
+			published, err := r.publish(ctx, pending)
+			if err != nil {
+				return delivered, fmt.Errorf("failed to publish event %d: %w", pending[0].ID, err)
+			}
+			for _, event := range pending[:published] {
+				err = r.outbox.MarkEventDelivered(ctx, event.ID)
+				if err != nil {
+					return delivered, err
+				}
+				delivered += 1
+			}
+			pending = pending[published:]
+		}
+		if len(events) < r.batchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// publish publishes the first of the events, or all leading EventOrderCreated
+// events together, and returns the number of published events.
+func (r Relay) publish(ctx context.Context, events []Event) (int, error) {
+	var created []Order
+	for _, event := range events {
+		if event.Type != EventOrderCreated {
+			break
+		}
+		created = append(created, event.Order)
+	}
+	if len(created) > 1 {
+		return len(created), r.messaging.PublishOrdersCreated(ctx, created)
+	}
+	return 1, r.publishEvent(ctx, events[0])
+}
+
+func (r Relay) publishEvent(ctx context.Context, event Event) error {
+	switch event.Type {
+	case EventOrderCreated:
+		return r.messaging.PublishOrderCreated(ctx, event.Order)
+	case EventOrderStatusChanged:
+		return r.messaging.PublishOrderStatusChanged(ctx, event.Order, event.PreviousStatus, event.CreatedAt)
+	default:
+		return fmt.Errorf("unknown event type %q", event.Type)
+	}
+}
+
+// Prune deletes the events delivered longer than the retention period ago
+// and returns their number.
+func (r Relay) Prune(ctx context.Context) (int64, error) {
+	return r.outbox.PruneDeliveredEvents(ctx, time.Now().Add(-r.retention))
+}
+
+// Run drains the outbox every poll interval and prunes it every prune
+// interval until ctx is cancelled. Failed deliveries are retried with
+// exponential backoff.
+func (r Relay) Run(ctx context.Context) {
+	r.logger.Info("starting outbox relay")
+	backoff := r.minBackoff
+	nextPrune := time.Now()
+	for {
+		if !time.Now().Before(nextPrune) {
+			nextPrune = time.Now().Add(r.pruneInterval)
+			pruned, err := r.Prune(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("failed to prune delivered events", "error", err.Error())
+			}
+			if pruned > 0 {
+				r.logger.Debug("pruned delivered events", "count", pruned)
+			}
+		}
+		wait := r.pollInterval
+		delivered, err := r.Drain(ctx)
+		if delivered > 0 {
+			r.logger.Debug("delivered events", "count", delivered)
+		}
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("failed to deliver events", "error", err.Error(), "retry_in", backoff.String())
+			wait = backoff
+			backoff = min(2*backoff, r.maxBackoff)
+		} else {
+			backoff = r.minBackoff
+		}
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay stopped")
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+type RelayConfig struct {
+	Outbox       Outbox
+	Messaging    MessagingSystem
+	Logger       *slog.Logger
+	BatchSize    int
+	PollInterval time.Duration
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	// Retention is how long delivered events are kept, seven days by
+	// default.
+	Retention     time.Duration
+	PruneInterval time.Duration
+}
+
+func NewRelay(config RelayConfig) Relay {
+	relay := Relay{
+		outbox:        config.Outbox,
+		messaging:     config.Messaging,
+		logger:        config.Logger,
+		batchSize:     config.BatchSize,
+		pollInterval:  config.PollInterval,
+		minBackoff:    config.MinBackoff,
+		maxBackoff:    config.MaxBackoff,
+		retention:     config.Retention,
+		pruneInterval: config.PruneInterval,
+	}
+	if relay.batchSize <= 0 {
+		relay.batchSize = 100
+	}
+	if relay.pollInterval <= 0 {
+		relay.pollInterval = time.Second
+	}
+	if relay.minBackoff <= 0 {
+		relay.minBackoff = 100 * time.Millisecond
+	}
+	if relay.maxBackoff < relay.minBackoff {
+		relay.maxBackoff = max(30*time.Second, relay.minBackoff)
+	}
+	if relay.retention <= 0 {
+		relay.retention = 7 * 24 * time.Hour
+	}
+	if relay.pruneInterval <= 0 {
+		relay.pruneInterval = time.Hour
+	}
+	return relay
+}
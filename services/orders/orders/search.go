@@ -0,0 +1,113 @@
+package orders
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxSearchLength = 200
+
+// SearchQuery is what Database.SearchOrders receives. Text is a web search
+// style query: orders match if they contain all of its words. CustomerID
+// restricts the search to the orders of the customer unless it is empty.
+type SearchQuery struct {
+	Text       string
+	CustomerID string
+}
+
+// Page selects Limit results starting at Offset.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+type SearchRequest struct {
+	Query  string
+	Cursor string
+	Limit  int
+}
+
+// searchCursor is the position of the next page of results. It remembers the
+// query so that it can not be used to page through the results of another one.
+type searchCursor struct {
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+func (c searchCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(value string) (searchCursor, error) {
+	var cursor searchCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.Offset < 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (r SearchRequest) query() (SearchQuery, Page, error) {
+	query := SearchQuery{Text: strings.TrimSpace(r.Query)}
+	page := Page{Limit: r.Limit}
+	if query.Text == "" {
+		return query, page, fmt.Errorf("%w: search query is required", ErrInvalidQuery)
+	}
+	if utf8.RuneCountInString(query.Text) > maxSearchLength {
+		return query, page, fmt.Errorf("%w: search query must not be longer than %d characters", ErrInvalidQuery, maxSearchLength)
+	}
+	if page.Limit == 0 {
+		page.Limit = DefaultPageSize
+	}
+	if page.Limit < 0 || page.Limit > MaxPageSize {
+		return query, page, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if r.Cursor != "" {
+		cursor, err := decodeSearchCursor(r.Cursor)
+		if err != nil {
+			return query, page, err
+		}
+		if cursor.Query != query.Text {
+			return query, page, ErrInvalidCursor
+		}
+		page.Offset = cursor.Offset
+	}
+	return query, page, nil
+}
+
+// SearchOrders returns a page of orders matching the query, best matches
+// first. Customers only find their own orders.
+func (e Engine) SearchOrders(ctx context.Context, principal Principal, request SearchRequest) (OrderPage, error) {
+	err := principal.validate()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	query, page, err := request.query()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	if !principal.Admin {
+		query.CustomerID = principal.CustomerID
+	}
+	limit := page.Limit
+	page.Limit += 1
+	found, err := e.database.SearchOrders(ctx, principal.TenantID, query, page)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	result := OrderPage{Orders: found}
+	if len(found) > limit {
+		result.Orders = found[:limit]
+		result.NextCursor = searchCursor{Query: query.Text, Offset: page.Offset + limit}.encode()
+	}
+	return result, nil
+}
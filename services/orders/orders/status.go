@@ -0,0 +1,46 @@
+package orders
+
+import "fmt"
+
+type Status string
+
+const (
+	StatusCreated   Status = "created"
+	StatusConfirmed Status = "confirmed"
+	StatusPaid      Status = "paid"
+	StatusShipped   Status = "shipped"
+	StatusDelivered Status = "delivered"
+	StatusCancelled Status = "cancelled"
+)
+
+var transitions = map[Status][]Status{
+	StatusCreated:   {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {},
+	StatusCancelled: {},
+}
+
+func (s Status) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func CanTransition(from Status, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e TransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %q to %q", e.From, e.To)
+}
@@ -0,0 +1,23 @@
+package orders
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const maxTenantLength = 64
+
+// ErrInvalidTenant is returned by Engine when a call has no tenant or the
+// tenant is malformed. Every order belongs to exactly one tenant and is
+// invisible to the others.
+var ErrInvalidTenant = errors.New("missing or invalid tenant")
+
+// ValidateTenant returns ErrInvalidTenant if tenant can not identify a
+// tenant.
+func ValidateTenant(tenant string) error {
+	if tenant == "" || len(tenant) > maxTenantLength || strings.ContainsFunc(tenant, unicode.IsControl) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
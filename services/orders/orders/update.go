@@ -0,0 +1,53 @@
+package orders
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrVersionMismatch  = errors.New("order version mismatch")
+	ErrOrderNotEditable = errors.New("order items can only be changed before the order is confirmed")
+)
+
+// OrderUpdate lists the fields to change. Nil fields are left as they are.
+type OrderUpdate struct {
+	Title *string
+	Items *[]Item
+}
+
+// UpdateOrder applies update to the order if its current version is version.
+// It returns ErrVersionMismatch otherwise, so concurrent updates are not lost.
+func (e Engine) UpdateOrder(ctx context.Context, principal Principal, id int, version int, update OrderUpdate) (Order, error) {
+	order, err := e.GetOrder(ctx, principal, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if order.Version != version {
+		return Order{}, ErrVersionMismatch
+	}
+	if update.Items != nil && order.Status != StatusCreated {
+		return Order{}, ErrOrderNotEditable
+	}
+	err = e.validator.validate(update.Title, update.Items)
+	if err != nil {
+		return Order{}, err
+	}
+	if update.Title != nil {
+		order.Title = *update.Title
+	}
+	if update.Items != nil {
+		total, err := CalculateTotal(*update.Items)
+		if err != nil {
+			return Order{}, err
+		}
+		order.Items = *update.Items
+		order.Total = total
+	}
+	order.Version += 1
+	err = e.database.UpdateOrder(ctx, order, version)
+	if err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
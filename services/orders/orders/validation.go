@@ -0,0 +1,126 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const DefaultMaxTitleLength = 200
+
+var ErrInvalidOrder = errors.New("invalid order")
+
+const (
+	CodeRequired          = "required"
+	CodeTooLong           = "too_long"
+	CodeInvalidCharacters = "invalid_characters"
+	CodeNotPositive       = "not_positive"
+	CodeNegative          = "negative"
+	CodeInvalidCurrency   = "invalid_currency"
+	CodeCurrencyMismatch  = "currency_mismatch"
+	CodeOverflow          = "overflow"
+)
+
+// FieldError describes why a single input field is invalid. Field is the
+// JSON path of the field in the API, e.g. "items[1].quantity".
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// ValidationError lists every invalid field of an order. It matches
+// ErrInvalidOrder, and ErrInvalidItem if any of the items is invalid.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return "invalid order: " + strings.Join(messages, "; ")
+}
+
+func (e ValidationError) Is(target error) bool {
+	if target == ErrInvalidOrder {
+		return true
+	}
+	if target != ErrInvalidItem {
+		return false
+	}
+	for _, field := range e.Fields {
+		if strings.HasPrefix(field.Field, "items") {
+			return true
+		}
+	}
+	return false
+}
+
+type validator struct {
+	maxTitleLength int
+	titlePattern   *regexp.Regexp
+}
+
+func (v validator) validateTitle(title string) []FieldError {
+	if strings.TrimSpace(title) == "" {
+		return []FieldError{{"title", CodeRequired, "must not be empty"}}
+	}
+	if utf8.RuneCountInString(title) > v.maxTitleLength {
+		return []FieldError{{"title", CodeTooLong, fmt.Sprintf("must be at most %d characters long", v.maxTitleLength)}}
+	}
+	if !utf8.ValidString(title) || strings.ContainsFunc(title, unicode.IsControl) {
+		return []FieldError{{"title", CodeInvalidCharacters, "must not contain control characters"}}
+	}
+	if v.titlePattern != nil && !v.titlePattern.MatchString(title) {
+		return []FieldError{{"title", CodeInvalidCharacters, "must match " + v.titlePattern.String()}}
+	}
+	return nil
+}
+
+func validateItems(items []Item) []FieldError {
+	var errs []FieldError
+	for idx, item := range items {
+		field := fmt.Sprintf("items[%d]", idx)
+		if item.SKU == "" {
+			errs = append(errs, FieldError{field + ".sku", CodeRequired, "must not be empty"})
+		}
+		if item.Quantity <= 0 {
+			errs = append(errs, FieldError{field + ".quantity", CodeNotPositive, "must be positive"})
+		}
+		if item.UnitPrice.IsNegative() {
+			errs = append(errs, FieldError{field + ".unit_price.amount", CodeNegative, "must not be negative"})
+		}
+		if ValidateCurrency(item.UnitPrice.Currency) != nil {
+			errs = append(errs, FieldError{field + ".unit_price.currency", CodeInvalidCurrency, "must be an ISO 4217 code"})
+		} else if item.UnitPrice.Currency != items[0].UnitPrice.Currency {
+			errs = append(errs, FieldError{field + ".unit_price.currency", CodeCurrencyMismatch, "must be the same for all items"})
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	_, err := CalculateTotal(items)
+	if err != nil {
+		return []FieldError{{"items", CodeOverflow, "total is too large"}}
+	}
+	return nil
+}
+
+func (v validator) validate(title *string, items *[]Item) error {
+	var errs []FieldError
+	if title != nil {
+		errs = append(errs, v.validateTitle(*title)...)
+	}
+	if items != nil {
+		errs = append(errs, validateItems(*items)...)
+	}
+	if errs != nil {
+		return ValidationError{Fields: errs}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@
 package orders_mock
 
 import (
+	context "context"
 	reflect "reflect"
 
 	orders "github.com/mrstecklo/micropet/services/orders/orders"
@@ -41,31 +42,163 @@ func (m *MockDatabase) EXPECT() *MockDatabaseMockRecorder {
 }
 
 // CreateOrder mocks base method.
-func (m *MockDatabase) CreateOrder(title string) (int, error) {
+func (m *MockDatabase) CreateOrder(ctx context.Context, order orders.Order) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrder", title)
+	ret := m.ctrl.Call(m, "CreateOrder", ctx, order)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateOrder indicates an expected call of CreateOrder.
-func (mr *MockDatabaseMockRecorder) CreateOrder(title any) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) CreateOrder(ctx, order any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockDatabase)(nil).CreateOrder), title)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockDatabase)(nil).CreateOrder), ctx, order)
+}
+
+// CreateOrderWithKey mocks base method.
+func (m *MockDatabase) CreateOrderWithKey(ctx context.Context, order orders.Order, key orders.IdempotencyKey) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrderWithKey", ctx, order, key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateOrderWithKey indicates an expected call of CreateOrderWithKey.
+func (mr *MockDatabaseMockRecorder) CreateOrderWithKey(ctx, order, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderWithKey", reflect.TypeOf((*MockDatabase)(nil).CreateOrderWithKey), ctx, order, key)
+}
+
+// CreateOrders mocks base method.
+func (m *MockDatabase) CreateOrders(ctx context.Context, arg1 []orders.Order) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrders", ctx, arg1)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrders indicates an expected call of CreateOrders.
+func (mr *MockDatabaseMockRecorder) CreateOrders(ctx, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrders", reflect.TypeOf((*MockDatabase)(nil).CreateOrders), ctx, arg1)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockDatabase) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockDatabaseMockRecorder) DeleteExpiredIdempotencyKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockDatabase)(nil).DeleteExpiredIdempotencyKeys), ctx)
+}
+
+// ExportOrders mocks base method.
+func (m *MockDatabase) ExportOrders(ctx context.Context, tenant string, filter orders.ListFilter, visit func(orders.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportOrders", ctx, tenant, filter, visit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportOrders indicates an expected call of ExportOrders.
+func (mr *MockDatabaseMockRecorder) ExportOrders(ctx, tenant, filter, visit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportOrders", reflect.TypeOf((*MockDatabase)(nil).ExportOrders), ctx, tenant, filter, visit)
 }
 
 // GetOrder mocks base method.
-func (m *MockDatabase) GetOrder(id int) (orders.Order, error) {
+func (m *MockDatabase) GetOrder(ctx context.Context, tenant string, id int) (orders.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrder", id)
+	ret := m.ctrl.Call(m, "GetOrder", ctx, tenant, id)
 	ret0, _ := ret[0].(orders.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrder indicates an expected call of GetOrder.
-func (mr *MockDatabaseMockRecorder) GetOrder(id any) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) GetOrder(ctx, tenant, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockDatabase)(nil).GetOrder), ctx, tenant, id)
+}
+
+// ImportOrders mocks base method.
+func (m *MockDatabase) ImportOrders(ctx context.Context, arg1 []orders.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportOrders", ctx, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportOrders indicates an expected call of ImportOrders.
+func (mr *MockDatabaseMockRecorder) ImportOrders(ctx, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportOrders", reflect.TypeOf((*MockDatabase)(nil).ImportOrders), ctx, arg1)
+}
+
+// ListOrders mocks base method.
+func (m *MockDatabase) ListOrders(ctx context.Context, tenant string, query orders.ListQuery) ([]orders.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, tenant, query)
+	ret0, _ := ret[0].([]orders.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockDatabaseMockRecorder) ListOrders(ctx, tenant, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockDatabase)(nil).ListOrders), ctx, tenant, query)
+}
+
+// SearchOrders mocks base method.
+func (m *MockDatabase) SearchOrders(ctx context.Context, tenant string, query orders.SearchQuery, page orders.Page) ([]orders.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchOrders", ctx, tenant, query, page)
+	ret0, _ := ret[0].([]orders.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchOrders indicates an expected call of SearchOrders.
+func (mr *MockDatabaseMockRecorder) SearchOrders(ctx, tenant, query, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrders", reflect.TypeOf((*MockDatabase)(nil).SearchOrders), ctx, tenant, query, page)
+}
+
+// UpdateOrder mocks base method.
+func (m *MockDatabase) UpdateOrder(ctx context.Context, order orders.Order, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrder", ctx, order, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrder indicates an expected call of UpdateOrder.
+func (mr *MockDatabaseMockRecorder) UpdateOrder(ctx, order, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockDatabase)(nil).UpdateOrder), ctx, order, version)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockDatabase) UpdateOrderStatus(ctx context.Context, order orders.Order, previous orders.Status, version int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, order, previous, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockDatabaseMockRecorder) UpdateOrderStatus(ctx, order, previous, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockDatabase)(nil).GetOrder), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockDatabase)(nil).UpdateOrderStatus), ctx, order, previous, version)
 }
@@ -10,7 +10,9 @@
 package orders_mock
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	orders "github.com/mrstecklo/micropet/services/orders/orders"
 	gomock "go.uber.org/mock/gomock"
@@ -41,15 +43,43 @@ func (m *MockMessagingSystem) EXPECT() *MockMessagingSystemMockRecorder {
 }
 
 // PublishOrderCreated mocks base method.
-func (m *MockMessagingSystem) PublishOrderCreated(order orders.Order) error {
+func (m *MockMessagingSystem) PublishOrderCreated(ctx context.Context, order orders.Order) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PublishOrderCreated", order)
+	ret := m.ctrl.Call(m, "PublishOrderCreated", ctx, order)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PublishOrderCreated indicates an expected call of PublishOrderCreated.
-func (mr *MockMessagingSystemMockRecorder) PublishOrderCreated(order any) *gomock.Call {
+func (mr *MockMessagingSystemMockRecorder) PublishOrderCreated(ctx, order any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOrderCreated", reflect.TypeOf((*MockMessagingSystem)(nil).PublishOrderCreated), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOrderCreated", reflect.TypeOf((*MockMessagingSystem)(nil).PublishOrderCreated), ctx, order)
+}
+
+// PublishOrderStatusChanged mocks base method.
+func (m *MockMessagingSystem) PublishOrderStatusChanged(ctx context.Context, order orders.Order, previous orders.Status, changedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOrderStatusChanged", ctx, order, previous, changedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishOrderStatusChanged indicates an expected call of PublishOrderStatusChanged.
+func (mr *MockMessagingSystemMockRecorder) PublishOrderStatusChanged(ctx, order, previous, changedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOrderStatusChanged", reflect.TypeOf((*MockMessagingSystem)(nil).PublishOrderStatusChanged), ctx, order, previous, changedAt)
+}
+
+// PublishOrdersCreated mocks base method.
+func (m *MockMessagingSystem) PublishOrdersCreated(ctx context.Context, arg1 []orders.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOrdersCreated", ctx, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishOrdersCreated indicates an expected call of PublishOrdersCreated.
+func (mr *MockMessagingSystemMockRecorder) PublishOrdersCreated(ctx, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOrdersCreated", reflect.TypeOf((*MockMessagingSystem)(nil).PublishOrdersCreated), ctx, arg1)
 }
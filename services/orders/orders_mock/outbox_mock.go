@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mrstecklo/micropet/services/orders/orders (interfaces: Outbox)
+//
+// Generated by this command:
+//
+//	mockgen -destination=orders_mock/outbox_mock.go -package=orders_mock github.com/mrstecklo/micropet/services/orders/orders Outbox
+//
+
+// Package orders_mock is a generated GoMock package.
+package orders_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	orders "github.com/mrstecklo/micropet/services/orders/orders"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOutbox is a mock of Outbox interface.
+type MockOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxMockRecorder
+	isgomock struct{}
+}
+
+// MockOutboxMockRecorder is the mock recorder for MockOutbox.
+type MockOutboxMockRecorder struct {
+	mock *MockOutbox
+}
+
+// NewMockOutbox creates a new mock instance.
+func NewMockOutbox(ctrl *gomock.Controller) *MockOutbox {
+	mock := &MockOutbox{ctrl: ctrl}
+	mock.recorder = &MockOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutbox) EXPECT() *MockOutboxMockRecorder {
+	return m.recorder
+}
+
+// MarkEventDelivered mocks base method.
+func (m *MockOutbox) MarkEventDelivered(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventDelivered", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventDelivered indicates an expected call of MarkEventDelivered.
+func (mr *MockOutboxMockRecorder) MarkEventDelivered(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventDelivered", reflect.TypeOf((*MockOutbox)(nil).MarkEventDelivered), ctx, id)
+}
+
+// PendingEvents mocks base method.
+func (m *MockOutbox) PendingEvents(ctx context.Context, limit int) ([]orders.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingEvents", ctx, limit)
+	ret0, _ := ret[0].([]orders.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingEvents indicates an expected call of PendingEvents.
+func (mr *MockOutboxMockRecorder) PendingEvents(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingEvents", reflect.TypeOf((*MockOutbox)(nil).PendingEvents), ctx, limit)
+}
+
+// PruneDeliveredEvents mocks base method.
+func (m *MockOutbox) PruneDeliveredEvents(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneDeliveredEvents", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneDeliveredEvents indicates an expected call of PruneDeliveredEvents.
+func (mr *MockOutboxMockRecorder) PruneDeliveredEvents(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneDeliveredEvents", reflect.TypeOf((*MockOutbox)(nil).PruneDeliveredEvents), ctx, before)
+}
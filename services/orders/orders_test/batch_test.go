@@ -0,0 +1,114 @@
+package orders_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestOrder(title string) orders.Order {
+	return orders.Order{
+		TenantID:   testTenant,
+		CustomerID: testCustomer,
+		Title:      title,
+		Status:     orders.StatusCreated,
+		CreatedAt:  testTime,
+		Version:    1,
+	}
+}
+
+func TestOrderEngine_CreateOrdersStoresBatch(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), []orders.Order{newTestOrder("duck"), newTestOrder("goose")}).
+		Return([]int{4, 5}, nil)
+	duck := newTestOrder("duck")
+	duck.ID = 4
+	goose := newTestOrder("goose")
+	goose.ID = 5
+
+	results, err := f.engine.CreateOrders(t.Context(), testPrincipal, []orders.OrderRequest{{Title: "duck"}, {Title: "goose"}}, orders.BatchAtomic)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []orders.BatchResult{{Order: duck}, {Order: goose}}, results)
+}
+
+func TestOrderEngine_CreateOrdersRejectsAtomicBatchWithInvalidOrder(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := f.engine.CreateOrders(t.Context(), testPrincipal, []orders.OrderRequest{{Title: "duck"}, {Title: ""}}, orders.BatchAtomic)
+
+	assert.Equal(t, orders.ValidationError{Fields: []orders.FieldError{
+		{Field: "[1].title", Code: orders.CodeRequired, Message: "must not be empty"},
+	}}, err)
+}
+
+func TestOrderEngine_CreateOrdersReportsInvalidOrdersOfPartialBatch(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), []orders.Order{newTestOrder("goose")}).
+		Return([]int{7}, nil)
+
+	results, err := f.engine.CreateOrders(t.Context(), testPrincipal, []orders.OrderRequest{{Title: ""}, {Title: "goose"}}, orders.BatchPartial)
+
+	require.Nil(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, errors.Is(results[0].Err, orders.ErrInvalidOrder), results[0].Err)
+	assert.Nil(t, results[1].Err)
+	assert.Equal(t, 7, results[1].Order.ID)
+}
+
+func TestOrderEngine_CreateOrdersDoesNotCallDatabaseWithoutValidOrders(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	results, err := f.engine.CreateOrders(t.Context(), testPrincipal, []orders.OrderRequest{{Title: ""}}, orders.BatchPartial)
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestOrderEngine_CreateOrdersReturnsDatabaseError(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	expectedError := errors.New("oh, no!")
+	f.databaseMock.EXPECT().
+		CreateOrders(gomock.Any(), gomock.Any()).
+		Return(nil, expectedError)
+
+	_, err := f.engine.CreateOrders(t.Context(), testPrincipal, []orders.OrderRequest{{Title: "duck"}}, orders.BatchPartial)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestOrderEngine_CreateOrdersRejectsInvalidBatch(t *testing.T) {
+	data := []struct {
+		name     string
+		requests []orders.OrderRequest
+		mode     orders.BatchMode
+	}{
+		{"Empty", nil, orders.BatchAtomic},
+		{"TooLarge", make([]orders.OrderRequest, orders.MaxBatchSize+1), orders.BatchAtomic},
+		{"UnknownMode", []orders.OrderRequest{{Title: "duck"}}, "best_effort"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				CreateOrders(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.CreateOrders(t.Context(), testPrincipal, d.requests, d.mode)
+
+			assert.True(t, errors.Is(err, orders.ErrInvalidBatch), err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package orders_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderEngine_ExportOrdersRestrictsCustomersToTheirOrders(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	filter := orders.ListFilter{Statuses: []orders.Status{orders.StatusPaid}}
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, orders.ListFilter{Statuses: filter.Statuses, CustomerID: testCustomer}, gomock.Any()).
+		Return(nil)
+
+	err := f.engine.ExportOrders(t.Context(), testPrincipal, filter, func(orders.Order) error { return nil })
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_ExportOrdersLetsAdminExportAllCustomers(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), testTenant, orders.ListFilter{}, gomock.Any()).
+		Return(nil)
+
+	err := f.engine.ExportOrders(t.Context(), adminPrincipal, orders.ListFilter{}, func(orders.Order) error { return nil })
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_ExportOrdersRejectsUnknownStatus(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		ExportOrders(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	err := f.engine.ExportOrders(t.Context(), testPrincipal, orders.ListFilter{Statuses: []orders.Status{"lost"}}, func(orders.Order) error { return nil })
+
+	assert.True(t, errors.Is(err, orders.ErrInvalidQuery), err)
+}
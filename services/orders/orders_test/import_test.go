@@ -0,0 +1,142 @@
+package orders_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type sliceSource struct {
+	records []orders.ImportRecord
+	err     error
+}
+
+func (s *sliceSource) Next() (orders.ImportRecord, error) {
+	if len(s.records) == 0 {
+		return orders.ImportRecord{}, s.err
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+func TestOrderEngine_ImportOrdersStoresValidRecords(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	malformed := errors.New("malformed record")
+	source := &sliceSource{
+		records: []orders.ImportRecord{
+			{Line: 2, CustomerID: "alice", Title: "duck"},
+			{Line: 3, CustomerID: "bob", Title: ""},
+			{Line: 4, Err: malformed},
+			{Line: 5, CustomerID: "bob", Title: "goose"},
+		},
+		err: io.EOF,
+	}
+	goose := newTestOrder("goose")
+	goose.CustomerID = "bob"
+	f.databaseMock.EXPECT().
+		ImportOrders(gomock.Any(), []orders.Order{newTestOrder("duck"), goose}).
+		Return(nil)
+
+	report, err := f.engine.ImportOrders(t.Context(), adminPrincipal, source, false)
+
+	require.Nil(t, err)
+	assert.Equal(t, 4, report.Records)
+	assert.Equal(t, 2, report.Imported)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 3, report.Errors[0].Line)
+	assert.Equal(t, orders.ValidationError{Fields: []orders.FieldError{
+		{Field: "title", Code: orders.CodeRequired, Message: "must not be empty"},
+	}}, report.Errors[0].Err)
+	assert.Equal(t, orders.ImportError{Line: 4, Err: malformed}, report.Errors[1])
+}
+
+func TestOrderEngine_ImportOrdersRequiresCustomer(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	source := &sliceSource{records: []orders.ImportRecord{{Line: 2, Title: ""}}, err: io.EOF}
+
+	report, err := f.engine.ImportOrders(t.Context(), adminPrincipal, source, true)
+
+	require.Nil(t, err)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, orders.ValidationError{Fields: []orders.FieldError{
+		{Field: "customer_id", Code: orders.CodeRequired, Message: "must not be empty"},
+		{Field: "title", Code: orders.CodeRequired, Message: "must not be empty"},
+	}}, report.Errors[0].Err)
+}
+
+func TestOrderEngine_ImportOrdersOnlyValidatesOnDryRun(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	source := &sliceSource{records: []orders.ImportRecord{{Line: 2, CustomerID: "alice", Title: "duck"}}, err: io.EOF}
+	f.databaseMock.EXPECT().
+		ImportOrders(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	report, err := f.engine.ImportOrders(t.Context(), adminPrincipal, source, true)
+
+	assert.Nil(t, err)
+	assert.Equal(t, orders.ImportReport{Records: 1}, report)
+}
+
+func TestOrderEngine_ImportOrdersStoresInBatches(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	source := &sliceSource{err: io.EOF}
+	for range 2500 {
+		source.records = append(source.records, orders.ImportRecord{CustomerID: "alice", Title: "duck"})
+	}
+	var batches []int
+	f.databaseMock.EXPECT().
+		ImportOrders(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, imported []orders.Order) error {
+			batches = append(batches, len(imported))
+			return nil
+		}).
+		Times(3)
+
+	report, err := f.engine.ImportOrders(t.Context(), adminPrincipal, source, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2500, report.Imported)
+	assert.Equal(t, []int{1000, 1000, 500}, batches)
+}
+
+func TestOrderEngine_ImportOrdersReturnsErrors(t *testing.T) {
+	readError := errors.New("connection reset")
+	databaseError := errors.New("oh, no!")
+	data := []struct {
+		name     string
+		source   *sliceSource
+		dbError  error
+		expected error
+	}{
+		{"ReadError", &sliceSource{err: readError}, nil, readError},
+		{"DatabaseError", &sliceSource{records: []orders.ImportRecord{{CustomerID: "alice", Title: "duck"}}, err: io.EOF}, databaseError, databaseError},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				ImportOrders(gomock.Any(), gomock.Any()).
+				Return(d.dbError).
+				AnyTimes()
+
+			_, err := f.engine.ImportOrders(t.Context(), adminPrincipal, d.source, false)
+
+			assert.Equal(t, d.expected, err)
+		})
+	}
+}
+
+func TestOrderEngine_ImportOrdersIsForbiddenForCustomers(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+
+	_, err := f.engine.ImportOrders(t.Context(), testPrincipal, &sliceSource{err: io.EOF}, true)
+
+	assert.True(t, errors.Is(err, orders.ErrForbidden), err)
+}
@@ -0,0 +1,179 @@
+package orders_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderEngine_ListOrdersAppliesDefaults(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{
+			Filter: orders.ListFilter{CustomerID: testCustomer},
+			Sort:   orders.SortByCreatedAtDesc,
+			Limit:  orders.DefaultPageSize + 1,
+		}).
+		Return(nil, nil)
+
+	page, err := f.engine.ListOrders(t.Context(), testPrincipal, orders.ListRequest{})
+
+	assert.Nil(t, err)
+	assert.Empty(t, page.Orders)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestOrderEngine_ListOrdersReturnsNextCursor(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	found := []orders.Order{
+		{ID: 1, CreatedAt: testTime},
+		{ID: 2, CreatedAt: testTime.Add(time.Hour)},
+		{ID: 3, CreatedAt: testTime.Add(2 * time.Hour)},
+	}
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{Filter: orders.ListFilter{CustomerID: testCustomer}, Sort: orders.SortByIDAsc, Limit: 3}).
+		Return(found, nil)
+
+	page, err := f.engine.ListOrders(t.Context(), testPrincipal, orders.ListRequest{Sort: orders.SortByIDAsc, Limit: 2})
+
+	require.Nil(t, err)
+	assert.Equal(t, found[:2], page.Orders)
+	cursor, err := orders.DecodeCursor(page.NextCursor)
+	require.Nil(t, err)
+	assert.Equal(t, orders.Cursor{Sort: orders.SortByIDAsc, CreatedAt: found[1].CreatedAt, ID: 2}, cursor)
+}
+
+func TestOrderEngine_ListOrdersOmitsNextCursorOnLastPage(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	found := []orders.Order{{ID: 1}, {ID: 2}}
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, gomock.Any()).
+		Return(found, nil)
+
+	page, err := f.engine.ListOrders(t.Context(), testPrincipal, orders.ListRequest{Limit: 2})
+
+	assert.Nil(t, err)
+	assert.Equal(t, found, page.Orders)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestOrderEngine_ListOrdersForwardsCursorAndFilter(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	cursor := orders.Cursor{Sort: orders.SortByCreatedAtAsc, CreatedAt: testTime, ID: 7}
+	filter := orders.ListFilter{
+		Statuses:      []orders.Status{orders.StatusPaid},
+		CreatedFrom:   testTime,
+		CreatedTo:     testTime.Add(time.Hour),
+		TitleContains: "duck",
+	}
+	expected := filter
+	expected.CustomerID = testCustomer
+
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{Filter: expected, Sort: orders.SortByCreatedAtAsc, After: &cursor, Limit: 11}).
+		Return(nil, nil)
+
+	_, err := f.engine.ListOrders(t.Context(), testPrincipal, orders.ListRequest{
+		Filter: filter,
+		Sort:   orders.SortByCreatedAtAsc,
+		Cursor: cursor.Encode(),
+		Limit:  10,
+	})
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_ListOrdersRestrictsCustomersToTheirOrders(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+
+	f.databaseMock.EXPECT().
+		ListOrders(gomock.Any(), testTenant, orders.ListQuery{
+			Filter: orders.ListFilter{CustomerID: testCustomer},
+			Sort:   orders.SortByCreatedAtDesc,
+			Limit:  orders.DefaultPageSize + 1,
+		}).
+		Return(nil, nil)
+
+	_, err := f.engine.ListOrders(t.Context(), testPrincipal, orders.ListRequest{Filter: orders.ListFilter{CustomerID: "bob"}})
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_ListOrdersLetsAdminFilterByCustomer(t *testing.T) {
+	data := []string{"", "bob"}
+	for _, customer := range data {
+		t.Run(customer, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+
+			f.databaseMock.EXPECT().
+				ListOrders(gomock.Any(), testTenant, orders.ListQuery{
+					Filter: orders.ListFilter{CustomerID: customer},
+					Sort:   orders.SortByCreatedAtDesc,
+					Limit:  orders.DefaultPageSize + 1,
+				}).
+				Return(nil, nil)
+
+			_, err := f.engine.ListOrders(t.Context(), adminPrincipal, orders.ListRequest{Filter: orders.ListFilter{CustomerID: customer}})
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestOrderEngine_ListOrdersRejectsInvalidRequest(t *testing.T) {
+	data := []struct {
+		name     string
+		request  orders.ListRequest
+		expected error
+	}{
+		{
+			"UnknownSort",
+			orders.ListRequest{Sort: "title"},
+			orders.ErrInvalidQuery,
+		},
+		{
+			"LimitTooLarge",
+			orders.ListRequest{Limit: orders.MaxPageSize + 1},
+			orders.ErrInvalidQuery,
+		},
+		{
+			"NegativeLimit",
+			orders.ListRequest{Limit: -1},
+			orders.ErrInvalidQuery,
+		},
+		{
+			"UnknownStatus",
+			orders.ListRequest{Filter: orders.ListFilter{Statuses: []orders.Status{"lost"}}},
+			orders.ErrInvalidQuery,
+		},
+		{
+			"MalformedCursor",
+			orders.ListRequest{Cursor: "!!!"},
+			orders.ErrInvalidCursor,
+		},
+		{
+			"CursorForOtherSort",
+			orders.ListRequest{Sort: orders.SortByIDAsc, Cursor: orders.Cursor{Sort: orders.SortByIDDesc, ID: 1}.Encode()},
+			orders.ErrInvalidCursor,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+
+			f.databaseMock.EXPECT().
+				ListOrders(gomock.Any(), testTenant, gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.ListOrders(t.Context(), testPrincipal, d.request)
+
+			assert.True(t, errors.Is(err, d.expected), err)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package orders_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney_AddSumsAmounts(t *testing.T) {
+	sum, err := orders.Money{Amount: 150, Currency: "EUR"}.Add(orders.Money{Amount: 320, Currency: "EUR"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, orders.Money{Amount: 470, Currency: "EUR"}, sum)
+}
+
+func TestMoney_AddRejectsCurrencyMismatch(t *testing.T) {
+	_, err := orders.Money{Amount: 150, Currency: "EUR"}.Add(orders.Money{Amount: 320, Currency: "USD"})
+
+	assert.True(t, errors.Is(err, orders.ErrCurrencyMismatch))
+}
+
+func TestMoney_AddDetectsOverflow(t *testing.T) {
+	_, err := orders.Money{Amount: math.MaxInt64, Currency: "EUR"}.Add(orders.Money{Amount: 1, Currency: "EUR"})
+
+	assert.True(t, errors.Is(err, orders.ErrAmountOverflow))
+}
+
+func TestMoney_MultiplyDetectsOverflow(t *testing.T) {
+	data := []struct {
+		amount int64
+		factor int64
+	}{
+		{math.MaxInt64, 2},
+		{math.MaxInt64/3 + 1, 3},
+		{math.MinInt64, -1},
+		{-1, math.MinInt64},
+	}
+	for _, d := range data {
+		_, err := orders.Money{Amount: d.amount, Currency: "EUR"}.Multiply(d.factor)
+
+		assert.True(t, errors.Is(err, orders.ErrAmountOverflow), d)
+	}
+}
+
+func TestValidateCurrency(t *testing.T) {
+	assert.Nil(t, orders.ValidateCurrency("EUR"))
+	assert.NotNil(t, orders.ValidateCurrency("eur"))
+	assert.NotNil(t, orders.ValidateCurrency("EU"))
+	assert.NotNil(t, orders.ValidateCurrency("EURO"))
+	assert.NotNil(t, orders.ValidateCurrency(""))
+}
@@ -1,9 +1,13 @@
 package orders_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mrstecklo/micropet/services/orders/orders"
 	"github.com/mrstecklo/micropet/services/orders/orders_mock"
@@ -11,11 +15,23 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+var testTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+const (
+	testTenant   = "acme"
+	testCustomer = "alice"
+)
+
+var (
+	testPrincipal  = orders.Principal{TenantID: testTenant, CustomerID: testCustomer}
+	otherPrincipal = orders.Principal{TenantID: testTenant, CustomerID: "bob"}
+	adminPrincipal = orders.Principal{TenantID: testTenant, CustomerID: "carol", Admin: true}
+)
+
 type ordersEngineFixture struct {
-	engine        orders.Engine
-	mockCtrl      *gomock.Controller
-	databaseMock  *orders_mock.MockDatabase
-	messagingMock *orders_mock.MockMessagingSystem
+	engine       orders.Engine
+	mockCtrl     *gomock.Controller
+	databaseMock *orders_mock.MockDatabase
 }
 
 func setUpOrdersEngineTest(t *testing.T) ordersEngineFixture {
@@ -23,26 +39,24 @@ func setUpOrdersEngineTest(t *testing.T) ordersEngineFixture {
 	mockCtrl := gomock.NewController(t, gomock.WithOverridableExpectations())
 	databaseMock := orders_mock.NewMockDatabase(mockCtrl)
 	databaseMock.EXPECT().
-		CreateOrder(gomock.Any()).
-		DoAndReturn(func(title string) (int, error) {
+		CreateOrder(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, order orders.Order) (int, error) {
 			dbRecordsCount += 1
 			return dbRecordsCount, nil
 		}).
 		AnyTimes()
-	messagingMock := orders_mock.NewMockMessagingSystem(mockCtrl)
-	messagingMock.EXPECT().
-		PublishOrderCreated(gomock.Any()).
+	databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil).
 		AnyTimes()
 	engine := orders.NewEngine(orders.Config{
-		Database:  databaseMock,
-		Messaging: messagingMock,
+		Database: databaseMock,
+		Now:      func() time.Time { return testTime },
 	})
 	return ordersEngineFixture{
-		engine:        engine,
-		mockCtrl:      mockCtrl,
-		databaseMock:  databaseMock,
-		messagingMock: messagingMock,
+		engine:       engine,
+		mockCtrl:     mockCtrl,
+		databaseMock: databaseMock,
 	}
 }
 
@@ -63,12 +77,12 @@ func TestOrderEngine_ForwardsCreateOrderToDatabase(t *testing.T) {
 			f := setUpOrdersEngineTest(t)
 
 			f.databaseMock.EXPECT().
-				CreateOrder(d.title).
+				CreateOrder(gomock.Any(), orders.Order{TenantID: testTenant, CustomerID: testCustomer, Title: d.title, Status: orders.StatusCreated, CreatedAt: testTime, Version: 1}).
 				Return(d.id, nil)
 
-			id, err := f.engine.CreateOrder(d.title)
+			order, err := f.engine.CreateOrder(t.Context(), testPrincipal, d.title, nil)
 
-			assert.Equal(t, d.id, id)
+			assert.Equal(t, d.id, order.ID)
 			assert.Nil(t, err)
 		})
 	}
@@ -78,68 +92,437 @@ func TestOrderEngine_ReturnsDatabaseCreateOrderError(t *testing.T) {
 	f := setUpOrdersEngineTest(t)
 	expectedError := errors.New("oh, no!")
 	f.databaseMock.EXPECT().
-		CreateOrder(gomock.Any()).
+		CreateOrder(gomock.Any(), gomock.Any()).
 		Return(0, expectedError)
 
-	_, err := f.engine.CreateOrder("someting")
+	_, err := f.engine.CreateOrder(t.Context(), testPrincipal, "someting", nil)
 
 	assert.Equal(t, expectedError, err)
 	assert.True(t, err == expectedError)
 }
 
-func TestOrderEngine_PublishesCreatedOrder(t *testing.T) {
+func TestOrderEngine_CreateOrderCalculatesTotal(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	items := []orders.Item{
+		{SKU: "milk", Quantity: 2, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}},
+		{SKU: "bread", Quantity: 1, UnitPrice: orders.Money{Amount: 320, Currency: "EUR"}},
+		{SKU: "gift", Quantity: 3, UnitPrice: orders.Money{Amount: 0, Currency: "EUR"}},
+	}
+	expected := orders.Order{
+		ID:         3,
+		TenantID:   testTenant,
+		CustomerID: testCustomer,
+		Title:      "groceries",
+		Status:     orders.StatusCreated,
+		Items:      items,
+		Total:      orders.Money{Amount: 620, Currency: "EUR"},
+		CreatedAt:  testTime,
+		Version:    1,
+	}
+	f.databaseMock.EXPECT().
+		CreateOrder(gomock.Any(), orders.Order{
+			TenantID:   expected.TenantID,
+			CustomerID: expected.CustomerID,
+			Title:      expected.Title,
+			Status:     expected.Status,
+			Items:      expected.Items,
+			Total:      expected.Total,
+			CreatedAt:  expected.CreatedAt,
+			Version:    expected.Version,
+		}).
+		Return(3, nil)
+
+	order, err := f.engine.CreateOrder(t.Context(), testPrincipal, "groceries", items)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, order)
+}
+
+func TestOrderEngine_CreateOrderRejectsInvalidItems(t *testing.T) {
 	data := []struct {
-		id    int
-		title string
+		name  string
+		items []orders.Item
 	}{
 		{
-			1, "some title",
+			"EmptySKU",
+			[]orders.Item{{SKU: "", Quantity: 1, UnitPrice: orders.Money{Amount: 1, Currency: "EUR"}}},
 		},
 		{
-			1421, "duckling",
+			"ZeroQuantity",
+			[]orders.Item{{SKU: "milk", Quantity: 0, UnitPrice: orders.Money{Amount: 1, Currency: "EUR"}}},
+		},
+		{
+			"NegativeQuantity",
+			[]orders.Item{{SKU: "milk", Quantity: -2, UnitPrice: orders.Money{Amount: 1, Currency: "EUR"}}},
 		},
+		{
+			"NegativePrice",
+			[]orders.Item{{SKU: "milk", Quantity: 1, UnitPrice: orders.Money{Amount: -1, Currency: "EUR"}}},
+		},
+		{
+			"InvalidCurrency",
+			[]orders.Item{{SKU: "milk", Quantity: 1, UnitPrice: orders.Money{Amount: 1, Currency: "eu"}}},
+		},
+		{
+			"MixedCurrencies",
+			[]orders.Item{
+				{SKU: "milk", Quantity: 1, UnitPrice: orders.Money{Amount: 1, Currency: "EUR"}},
+				{SKU: "bread", Quantity: 1, UnitPrice: orders.Money{Amount: 1, Currency: "USD"}},
+			},
+		},
+		{
+			"Overflow",
+			[]orders.Item{{SKU: "milk", Quantity: 3, UnitPrice: orders.Money{Amount: math.MaxInt64 / 2, Currency: "EUR"}}},
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+
+			f.databaseMock.EXPECT().
+				CreateOrder(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.CreateOrder(t.Context(), testPrincipal, "something", d.items)
+
+			assert.True(t, errors.Is(err, orders.ErrInvalidItem), err)
+		})
+	}
+}
+
+func TestOrderEngine_ForwardsGetOrderToDatabase(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	expected := orders.Order{ID: 1421, CustomerID: testCustomer, Title: "duckling"}
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 1421).
+		Return(expected, nil)
+
+	order, err := f.engine.GetOrder(t.Context(), testPrincipal, 1421)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, order)
+}
+
+func TestOrderEngine_GetOrderReturnsErrNotFound(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, gomock.Any()).
+		Return(orders.Order{}, orders.ErrNotFound)
+
+	_, err := f.engine.GetOrder(t.Context(), testPrincipal, 1)
+
+	assert.True(t, errors.Is(err, orders.ErrNotFound))
+}
+
+func TestOrderEngine_ChangeOrderStatusAllowsLegalTransitions(t *testing.T) {
+	data := []struct {
+		from orders.Status
+		to   orders.Status
+	}{
+		{orders.StatusCreated, orders.StatusConfirmed},
+		{orders.StatusCreated, orders.StatusCancelled},
+		{orders.StatusConfirmed, orders.StatusPaid},
+		{orders.StatusConfirmed, orders.StatusCancelled},
+		{orders.StatusPaid, orders.StatusShipped},
+		{orders.StatusPaid, orders.StatusCancelled},
+		{orders.StatusShipped, orders.StatusDelivered},
 	}
 	for _, d := range data {
 		t.Run(fmt.Sprint(d), func(t *testing.T) {
 			f := setUpOrdersEngineTest(t)
 			f.databaseMock.EXPECT().
-				CreateOrder(gomock.Any()).
-				Return(d.id, nil)
+				GetOrder(gomock.Any(), testTenant, 7).
+				Return(orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: d.from, Version: 3}, nil)
 
-			f.messagingMock.EXPECT().
-				PublishOrderCreated(orders.Order{
-					ID:    d.id,
-					Title: d.title,
-				}).
+			f.databaseMock.EXPECT().
+				UpdateOrderStatus(gomock.Any(), orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: d.to, Version: 4}, d.from, 3).
 				Return(nil)
 
-			_, _ = f.engine.CreateOrder(d.title)
+			order, err := f.engine.ChangeOrderStatus(t.Context(), adminPrincipal, 7, d.to)
+
+			assert.Nil(t, err)
+			assert.Equal(t, orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: d.to, Version: 4}, order)
 		})
 	}
 }
 
-func TestOrderEngine_DoesNotPublishOrderOnDatabaseError(t *testing.T) {
+func TestOrderEngine_ChangeOrderStatusAllowsCustomersToCancel(t *testing.T) {
 	f := setUpOrdersEngineTest(t)
 	f.databaseMock.EXPECT().
-		CreateOrder(gomock.Any()).
-		Return(0, errors.New("oh, no!"))
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusPaid, Version: 3}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusCancelled, Version: 4}, orders.StatusPaid, 3).
+		Return(nil)
+
+	order, err := f.engine.ChangeOrderStatus(t.Context(), testPrincipal, 7, orders.StatusCancelled)
+
+	assert.Nil(t, err)
+	assert.Equal(t, orders.StatusCancelled, order.Status)
+}
+
+func TestOrderEngine_ChangeOrderStatusIsForbiddenForCustomers(t *testing.T) {
+	data := []struct {
+		from orders.Status
+		to   orders.Status
+	}{
+		{orders.StatusCreated, orders.StatusConfirmed},
+		{orders.StatusConfirmed, orders.StatusPaid},
+		{orders.StatusPaid, orders.StatusShipped},
+		{orders.StatusShipped, orders.StatusDelivered},
+	}
+	for _, d := range data {
+		t.Run(fmt.Sprint(d), func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, 7).
+				Return(orders.Order{ID: 7, CustomerID: testCustomer, Status: d.from, Version: 3}, nil)
+
+			f.databaseMock.EXPECT().
+				UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.ChangeOrderStatus(t.Context(), testPrincipal, 7, d.to)
+
+			assert.True(t, errors.Is(err, orders.ErrForbidden), err)
+		})
+	}
+}
+
+func TestOrderEngine_ChangeOrderStatusRejectsIllegalTransitions(t *testing.T) {
+	data := []struct {
+		from orders.Status
+		to   orders.Status
+	}{
+		{orders.StatusCreated, orders.StatusCreated},
+		{orders.StatusCreated, orders.StatusPaid},
+		{orders.StatusConfirmed, orders.StatusShipped},
+		{orders.StatusShipped, orders.StatusCancelled},
+		{orders.StatusDelivered, orders.StatusCancelled},
+		{orders.StatusCancelled, orders.StatusConfirmed},
+		{orders.StatusCreated, orders.Status("lost")},
+	}
+	for _, d := range data {
+		t.Run(fmt.Sprint(d), func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, 7).
+				Return(orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: d.from}, nil)
 
-	f.messagingMock.EXPECT().
-		PublishOrderCreated(gomock.Any()).
-		Times(0)
+			f.databaseMock.EXPECT().
+				UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.ChangeOrderStatus(t.Context(), testPrincipal, 7, d.to)
 
-	_, _ = f.engine.CreateOrder("someting")
+			assert.Equal(t, orders.TransitionError{From: d.from, To: d.to}, err)
+		})
+	}
 }
 
-func TestOrderEngine_ReturnsPublishOrderCreatedError(t *testing.T) {
+func TestOrderEngine_ChangeOrderStatusReturnsDatabaseErrors(t *testing.T) {
 	f := setUpOrdersEngineTest(t)
-	expectedError := errors.New("failed to publish")
-	f.messagingMock.EXPECT().
-		PublishOrderCreated(gomock.Any()).
+	expectedError := orders.TransitionError{From: orders.StatusCancelled, To: orders.StatusConfirmed}
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusCreated}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(expectedError)
 
-	_, err := f.engine.CreateOrder("someting")
+	_, err := f.engine.ChangeOrderStatus(t.Context(), adminPrincipal, 7, orders.StatusConfirmed)
 
 	assert.Equal(t, expectedError, err)
-	assert.True(t, err == expectedError)
+}
+
+func TestOrderEngine_ChangeOrderStatusReturnsErrNotFound(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, gomock.Any()).
+		Return(orders.Order{}, orders.ErrNotFound)
+
+	_, err := f.engine.ChangeOrderStatus(t.Context(), testPrincipal, 7, orders.StatusConfirmed)
+
+	assert.True(t, errors.Is(err, orders.ErrNotFound))
+}
+
+func TestOrderEngine_HidesOrdersOfOtherCustomers(t *testing.T) {
+	title := "goose"
+	calls := map[string]func(f ordersEngineFixture) error{
+		"GetOrder": func(f ordersEngineFixture) error {
+			_, err := f.engine.GetOrder(t.Context(), otherPrincipal, 7)
+			return err
+		},
+		"ChangeOrderStatus": func(f ordersEngineFixture) error {
+			_, err := f.engine.ChangeOrderStatus(t.Context(), otherPrincipal, 7, orders.StatusConfirmed)
+			return err
+		},
+		"UpdateOrder": func(f ordersEngineFixture) error {
+			_, err := f.engine.UpdateOrder(t.Context(), otherPrincipal, 7, 1, orders.OrderUpdate{Title: &title})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, 7).
+				Return(orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusCreated, Version: 1}, nil)
+
+			f.databaseMock.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			f.databaseMock.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			err := call(f)
+
+			assert.True(t, errors.Is(err, orders.ErrNotFound), err)
+		})
+	}
+}
+
+func TestOrderEngine_AdminAccessesOrdersOfAllCustomers(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	expected := orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusCreated, Version: 1}
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(expected, nil)
+
+	order, err := f.engine.GetOrder(t.Context(), adminPrincipal, 7)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, order)
+}
+
+func TestOrderEngine_CreateOrderIdempotentStoresKey(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), orders.Order{TenantID: testTenant, CustomerID: testCustomer, Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, order orders.Order, key orders.IdempotencyKey) (int, bool, error) {
+			assert.Equal(t, "some key", key.Key)
+			assert.NotEmpty(t, key.Fingerprint)
+			assert.Equal(t, 24*time.Hour, key.TTL)
+			return 5, true, nil
+		})
+
+	order, created, err := f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "some key", "duck", nil)
+
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, orders.Order{ID: 5, CustomerID: testCustomer, TenantID: testTenant, Title: "duck", Status: orders.StatusCreated, CreatedAt: testTime, Version: 1}, order)
+}
+
+func TestOrderEngine_CreateOrderIdempotentReturnsOriginalOrderOnRetry(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	original := orders.Order{ID: 5, CustomerID: testCustomer, Title: "duck", Status: orders.StatusConfirmed}
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(5, false, nil)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 5).
+		Return(original, nil)
+
+	order, created, err := f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "some key", "duck", nil)
+
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, original, order)
+}
+
+func TestOrderEngine_CreateOrderIdempotentFingerprintsRequest(t *testing.T) {
+	var fingerprints []string
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, order orders.Order, key orders.IdempotencyKey) (int, bool, error) {
+			fingerprints = append(fingerprints, key.Fingerprint)
+			return 1, true, nil
+		}).
+		Times(5)
+	items := []orders.Item{{SKU: "milk", Quantity: 1, UnitPrice: orders.Money{Amount: 100, Currency: "EUR"}}}
+	otherItems := []orders.Item{{SKU: "milk", Quantity: 2, UnitPrice: orders.Money{Amount: 100, Currency: "EUR"}}}
+
+	_, _, _ = f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "key", "duck", items)
+	_, _, _ = f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "key", "duck", items)
+	_, _, _ = f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "key", "goose", items)
+	_, _, _ = f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "key", "duck", otherItems)
+	_, _, _ = f.engine.CreateOrderIdempotent(t.Context(), otherPrincipal, "key", "duck", items)
+
+	assert.Equal(t, fingerprints[0], fingerprints[1])
+	assert.NotEqual(t, fingerprints[0], fingerprints[2])
+	assert.NotEqual(t, fingerprints[0], fingerprints[3])
+	assert.NotEqual(t, fingerprints[0], fingerprints[4])
+}
+
+func TestOrderEngine_CreateOrderIdempotentReturnsDatabaseError(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		CreateOrderWithKey(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(0, false, orders.ErrIdempotencyKeyReused)
+
+	_, _, err := f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, "some key", "duck", nil)
+
+	assert.Equal(t, orders.ErrIdempotencyKeyReused, err)
+}
+
+func TestOrderEngine_CreateOrderIdempotentRejectsInvalidKey(t *testing.T) {
+	for _, key := range []string{"", strings.Repeat("k", 256)} {
+		f := setUpOrdersEngineTest(t)
+		f.databaseMock.EXPECT().
+			CreateOrderWithKey(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+
+		_, _, err := f.engine.CreateOrderIdempotent(t.Context(), testPrincipal, key, "duck", nil)
+
+		assert.Equal(t, orders.ErrInvalidIdempotencyKey, err)
+	}
+}
+
+func TestOrderEngine_RejectsInvalidPrincipal(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	title := "duck"
+	calls := map[string]func(principal orders.Principal) error{
+		"CreateOrder": func(principal orders.Principal) error {
+			_, err := f.engine.CreateOrder(t.Context(), principal, "duck", nil)
+			return err
+		},
+		"CreateOrderIdempotent": func(principal orders.Principal) error {
+			_, _, err := f.engine.CreateOrderIdempotent(t.Context(), principal, "key", "duck", nil)
+			return err
+		},
+		"GetOrder": func(principal orders.Principal) error {
+			_, err := f.engine.GetOrder(t.Context(), principal, 1)
+			return err
+		},
+		"ListOrders": func(principal orders.Principal) error {
+			_, err := f.engine.ListOrders(t.Context(), principal, orders.ListRequest{})
+			return err
+		},
+		"ChangeOrderStatus": func(principal orders.Principal) error {
+			_, err := f.engine.ChangeOrderStatus(t.Context(), principal, 1, orders.StatusConfirmed)
+			return err
+		},
+		"UpdateOrder": func(principal orders.Principal) error {
+			_, err := f.engine.UpdateOrder(t.Context(), principal, 1, 1, orders.OrderUpdate{Title: &title})
+			return err
+		},
+	}
+	principals := []struct {
+		principal orders.Principal
+		expected  error
+	}{
+		{orders.Principal{CustomerID: testCustomer}, orders.ErrInvalidTenant},
+		{orders.Principal{TenantID: strings.Repeat("a", 65), CustomerID: testCustomer}, orders.ErrInvalidTenant},
+		{orders.Principal{TenantID: "acme\n", CustomerID: testCustomer}, orders.ErrInvalidTenant},
+		{orders.Principal{TenantID: testTenant}, orders.ErrUnauthenticated},
+	}
+	for name, call := range calls {
+		for _, d := range principals {
+			t.Run(name, func(t *testing.T) {
+				f.databaseMock.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Times(0)
+
+				err := call(d.principal)
+
+				assert.True(t, errors.Is(err, d.expected), err)
+			})
+		}
+	}
 }
@@ -0,0 +1,196 @@
+package orders_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/mrstecklo/micropet/services/orders/orders_mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type relayFixture struct {
+	relay         orders.Relay
+	mockCtrl      *gomock.Controller
+	outboxMock    *orders_mock.MockOutbox
+	messagingMock *orders_mock.MockMessagingSystem
+}
+
+func setUpRelayTest(t *testing.T) relayFixture {
+	mockCtrl := gomock.NewController(t)
+	outboxMock := orders_mock.NewMockOutbox(mockCtrl)
+	messagingMock := orders_mock.NewMockMessagingSystem(mockCtrl)
+	relay := orders.NewRelay(orders.RelayConfig{
+		Outbox:       outboxMock,
+		Messaging:    messagingMock,
+		Logger:       slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		BatchSize:    2,
+		PollInterval: time.Millisecond,
+		MinBackoff:   time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+	})
+	return relayFixture{
+		relay:         relay,
+		mockCtrl:      mockCtrl,
+		outboxMock:    outboxMock,
+		messagingMock: messagingMock,
+	}
+}
+
+func TestRelay_PublishesPendingEventsInOrder(t *testing.T) {
+	f := setUpRelayTest(t)
+	created := orders.Order{ID: 1, Title: "duck", Status: orders.StatusCreated}
+	confirmed := orders.Order{ID: 1, Title: "duck", Status: orders.StatusConfirmed}
+	confirmedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), 2).
+		Return([]orders.Event{
+			{ID: 10, Type: orders.EventOrderCreated, Order: created},
+			{ID: 11, Type: orders.EventOrderStatusChanged, Order: confirmed, PreviousStatus: orders.StatusCreated, CreatedAt: confirmedAt},
+		}, nil)
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), 2).
+		Return(nil, nil)
+
+	gomock.InOrder(
+		f.messagingMock.EXPECT().PublishOrderCreated(gomock.Any(), created).Return(nil),
+		f.outboxMock.EXPECT().MarkEventDelivered(gomock.Any(), int64(10)).Return(nil),
+		f.messagingMock.EXPECT().PublishOrderStatusChanged(gomock.Any(), confirmed, orders.StatusCreated, confirmedAt).Return(nil),
+		f.outboxMock.EXPECT().MarkEventDelivered(gomock.Any(), int64(11)).Return(nil),
+	)
+
+	delivered, err := f.relay.Drain(t.Context())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, delivered)
+}
+
+func TestRelay_PublishesCreatedEventsInBulk(t *testing.T) {
+	f := setUpRelayTest(t)
+	confirmed := orders.Order{ID: 1, Status: orders.StatusConfirmed}
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), 2).
+		Return([]orders.Event{
+			{ID: 10, Type: orders.EventOrderCreated, Order: orders.Order{ID: 1}},
+			{ID: 11, Type: orders.EventOrderCreated, Order: orders.Order{ID: 2}},
+		}, nil)
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), 2).
+		Return([]orders.Event{
+			{ID: 12, Type: orders.EventOrderStatusChanged, Order: confirmed, PreviousStatus: orders.StatusCreated},
+			{ID: 13, Type: orders.EventOrderCreated, Order: orders.Order{ID: 3}},
+		}, nil)
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), 2).
+		Return(nil, nil)
+
+	gomock.InOrder(
+		f.messagingMock.EXPECT().PublishOrdersCreated(gomock.Any(), []orders.Order{{ID: 1}, {ID: 2}}).Return(nil),
+		f.outboxMock.EXPECT().MarkEventDelivered(gomock.Any(), int64(10)).Return(nil),
+		f.outboxMock.EXPECT().MarkEventDelivered(gomock.Any(), int64(11)).Return(nil),
+		f.messagingMock.EXPECT().PublishOrderStatusChanged(gomock.Any(), confirmed, orders.StatusCreated, time.Time{}).Return(nil),
+		f.outboxMock.EXPECT().MarkEventDelivered(gomock.Any(), int64(12)).Return(nil),
+		f.messagingMock.EXPECT().PublishOrderCreated(gomock.Any(), orders.Order{ID: 3}).Return(nil),
+		f.outboxMock.EXPECT().MarkEventDelivered(gomock.Any(), int64(13)).Return(nil),
+	)
+
+	delivered, err := f.relay.Drain(t.Context())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 4, delivered)
+}
+
+func TestRelay_DoesNotMarkEventDeliveredOnPublishError(t *testing.T) {
+	f := setUpRelayTest(t)
+	expectedError := errors.New("failed to publish")
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), 2).
+		Return([]orders.Event{
+			{ID: 10, Type: orders.EventOrderCreated, Order: orders.Order{ID: 1}},
+			{ID: 11, Type: orders.EventOrderCreated, Order: orders.Order{ID: 2}},
+		}, nil)
+	f.messagingMock.EXPECT().
+		PublishOrdersCreated(gomock.Any(), []orders.Order{{ID: 1}, {ID: 2}}).
+		Return(expectedError)
+
+	f.outboxMock.EXPECT().
+		MarkEventDelivered(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	delivered, err := f.relay.Drain(t.Context())
+
+	assert.True(t, errors.Is(err, expectedError))
+	assert.Equal(t, 0, delivered)
+}
+
+func TestRelay_ReturnsPendingEventsError(t *testing.T) {
+	f := setUpRelayTest(t)
+	expectedError := errors.New("oh, no!")
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), gomock.Any()).
+		Return(nil, expectedError)
+
+	_, err := f.relay.Drain(t.Context())
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestRelay_PrunesEventsDeliveredBeforeRetention(t *testing.T) {
+	f := setUpRelayTest(t)
+	f.outboxMock.EXPECT().
+		PruneDeliveredEvents(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, before time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), before, time.Minute)
+			return 3, nil
+		})
+
+	pruned, err := f.relay.Prune(t.Context())
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), pruned)
+}
+
+func TestRelay_RunRetriesFailedEvents(t *testing.T) {
+	f := setUpRelayTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	event := orders.Event{ID: 10, Type: orders.EventOrderCreated, Order: orders.Order{ID: 1}}
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), gomock.Any()).
+		Return([]orders.Event{event}, nil).
+		Times(3)
+	f.outboxMock.EXPECT().
+		PendingEvents(gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		AnyTimes()
+	f.outboxMock.EXPECT().
+		PruneDeliveredEvents(gomock.Any(), gomock.Any()).
+		Return(int64(0), nil).
+		AnyTimes()
+	gomock.InOrder(
+		f.messagingMock.EXPECT().PublishOrderCreated(gomock.Any(), event.Order).Return(errors.New("failed to publish")),
+		f.messagingMock.EXPECT().PublishOrderCreated(gomock.Any(), event.Order).Return(errors.New("failed to publish")),
+		f.messagingMock.EXPECT().PublishOrderCreated(gomock.Any(), event.Order).Return(nil),
+	)
+
+	f.outboxMock.EXPECT().
+		MarkEventDelivered(gomock.Any(), int64(10)).
+		Do(func(context.Context, int64) { cancel() }).
+		Return(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.relay.Run(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not deliver event")
+	}
+}
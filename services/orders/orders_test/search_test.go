@@ -0,0 +1,93 @@
+package orders_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderEngine_SearchOrdersAppliesDefaults(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+
+	f.databaseMock.EXPECT().
+		SearchOrders(gomock.Any(), testTenant, orders.SearchQuery{Text: "duck", CustomerID: testCustomer}, orders.Page{Limit: orders.DefaultPageSize + 1}).
+		Return(nil, nil)
+
+	page, err := f.engine.SearchOrders(t.Context(), testPrincipal, orders.SearchRequest{Query: "  duck "})
+
+	assert.Nil(t, err)
+	assert.Empty(t, page.Orders)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestOrderEngine_SearchOrdersLetsAdminSearchAllCustomers(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+
+	f.databaseMock.EXPECT().
+		SearchOrders(gomock.Any(), testTenant, orders.SearchQuery{Text: "duck"}, gomock.Any()).
+		Return(nil, nil)
+
+	_, err := f.engine.SearchOrders(t.Context(), adminPrincipal, orders.SearchRequest{Query: "duck"})
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_SearchOrdersPagesThroughResults(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	query := orders.SearchQuery{Text: "duck", CustomerID: testCustomer}
+	f.databaseMock.EXPECT().
+		SearchOrders(gomock.Any(), testTenant, query, orders.Page{Offset: 0, Limit: 3}).
+		Return([]orders.Order{{ID: 5}, {ID: 4}, {ID: 3}}, nil)
+	first, err := f.engine.SearchOrders(t.Context(), testPrincipal, orders.SearchRequest{Query: "duck", Limit: 2})
+	require.Nil(t, err)
+
+	f.databaseMock.EXPECT().
+		SearchOrders(gomock.Any(), testTenant, query, orders.Page{Offset: 2, Limit: 3}).
+		Return([]orders.Order{{ID: 3}}, nil)
+
+	second, err := f.engine.SearchOrders(t.Context(), testPrincipal, orders.SearchRequest{Query: "duck", Cursor: first.NextCursor, Limit: 2})
+
+	require.Nil(t, err)
+	assert.Equal(t, []orders.Order{{ID: 5}, {ID: 4}}, first.Orders)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, []orders.Order{{ID: 3}}, second.Orders)
+	assert.Empty(t, second.NextCursor)
+}
+
+func TestOrderEngine_SearchOrdersRejectsInvalidRequest(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		SearchOrders(gomock.Any(), testTenant, gomock.Any(), gomock.Any()).
+		Return([]orders.Order{{ID: 2}, {ID: 1}}, nil)
+	page, err := f.engine.SearchOrders(t.Context(), testPrincipal, orders.SearchRequest{Query: "duck", Limit: 1})
+	require.Nil(t, err)
+	data := []struct {
+		name     string
+		request  orders.SearchRequest
+		expected error
+	}{
+		{"EmptyQuery", orders.SearchRequest{Query: " "}, orders.ErrInvalidQuery},
+		{"QueryTooLong", orders.SearchRequest{Query: strings.Repeat("a", 201)}, orders.ErrInvalidQuery},
+		{"LimitTooLarge", orders.SearchRequest{Query: "duck", Limit: orders.MaxPageSize + 1}, orders.ErrInvalidQuery},
+		{"MalformedCursor", orders.SearchRequest{Query: "duck", Cursor: "!!!"}, orders.ErrInvalidCursor},
+		{"CursorForOtherQuery", orders.SearchRequest{Query: "goose", Cursor: page.NextCursor}, orders.ErrInvalidCursor},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+
+			f.databaseMock.EXPECT().
+				SearchOrders(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.SearchOrders(t.Context(), testPrincipal, d.request)
+
+			assert.True(t, errors.Is(err, d.expected), err)
+		})
+	}
+}
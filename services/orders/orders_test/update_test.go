@@ -0,0 +1,117 @@
+package orders_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderEngine_UpdateOrderChangesTitle(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	title := "goose"
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: orders.StatusPaid, Version: 3}, nil)
+
+	f.databaseMock.EXPECT().
+		UpdateOrder(gomock.Any(), orders.Order{ID: 7, CustomerID: testCustomer, Title: "goose", Status: orders.StatusPaid, Version: 4}, 3).
+		Return(nil)
+
+	order, err := f.engine.UpdateOrder(t.Context(), testPrincipal, 7, 3, orders.OrderUpdate{Title: &title})
+
+	assert.Nil(t, err)
+	assert.Equal(t, orders.Order{ID: 7, CustomerID: testCustomer, Title: "goose", Status: orders.StatusPaid, Version: 4}, order)
+}
+
+func TestOrderEngine_UpdateOrderRecalculatesTotal(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	items := []orders.Item{{SKU: "milk", Quantity: 3, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}}
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: orders.StatusCreated, Version: 1}, nil)
+
+	f.databaseMock.EXPECT().
+		UpdateOrder(gomock.Any(), orders.Order{
+			ID:         7,
+			CustomerID: testCustomer,
+			Title:      "duck",
+			Status:     orders.StatusCreated,
+			Items:      items,
+			Total:      orders.Money{Amount: 450, Currency: "EUR"},
+			Version:    2,
+		}, 1).
+		Return(nil)
+
+	_, err := f.engine.UpdateOrder(t.Context(), testPrincipal, 7, 1, orders.OrderUpdate{Items: &items})
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_UpdateOrderRejectsErrors(t *testing.T) {
+	title := "goose"
+	items := []orders.Item{{SKU: "milk", Quantity: 3, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}}
+	invalidItems := []orders.Item{{SKU: "milk", Quantity: 0, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}}}
+	data := []struct {
+		name     string
+		order    orders.Order
+		version  int
+		update   orders.OrderUpdate
+		expected error
+	}{
+		{
+			"VersionMismatch",
+			orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusCreated, Version: 2},
+			1,
+			orders.OrderUpdate{Title: &title},
+			orders.ErrVersionMismatch,
+		},
+		{
+			"ItemsOfConfirmedOrder",
+			orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusConfirmed, Version: 1},
+			1,
+			orders.OrderUpdate{Items: &items},
+			orders.ErrOrderNotEditable,
+		},
+		{
+			"InvalidItems",
+			orders.Order{ID: 7, CustomerID: testCustomer, Status: orders.StatusCreated, Version: 1},
+			1,
+			orders.OrderUpdate{Items: &invalidItems},
+			orders.ErrInvalidItem,
+		},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				GetOrder(gomock.Any(), testTenant, 7).
+				Return(d.order, nil)
+
+			f.databaseMock.EXPECT().
+				UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.UpdateOrder(t.Context(), testPrincipal, 7, d.version, d.update)
+
+			assert.True(t, errors.Is(err, d.expected), err)
+		})
+	}
+}
+
+func TestOrderEngine_UpdateOrderReturnsDatabaseError(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	title := "goose"
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(orders.Order{ID: 7, CustomerID: testCustomer, Version: 1}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(orders.ErrVersionMismatch)
+
+	_, err := f.engine.UpdateOrder(t.Context(), testPrincipal, 7, 1, orders.OrderUpdate{Title: &title})
+
+	assert.Equal(t, orders.ErrVersionMismatch, err)
+}
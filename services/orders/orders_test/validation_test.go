@@ -0,0 +1,130 @@
+package orders_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mrstecklo/micropet/services/orders/orders"
+	"github.com/mrstecklo/micropet/services/orders/orders_mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderEngine_CreateOrderReturnsValidationErrorOnInvalidTitle(t *testing.T) {
+	data := []struct {
+		name  string
+		title string
+		code  string
+	}{
+		{"Empty", "", orders.CodeRequired},
+		{"Blank", "  \t", orders.CodeRequired},
+		{"TooLong", strings.Repeat("ü", orders.DefaultMaxTitleLength+1), orders.CodeTooLong},
+		{"ControlCharacter", "duck\x00", orders.CodeInvalidCharacters},
+		{"InvalidUTF8", "duck\xff", orders.CodeInvalidCharacters},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			f := setUpOrdersEngineTest(t)
+			f.databaseMock.EXPECT().
+				CreateOrder(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			_, err := f.engine.CreateOrder(t.Context(), testPrincipal, d.title, nil)
+
+			var validationErr orders.ValidationError
+			require.True(t, errors.As(err, &validationErr), err)
+			require.Len(t, validationErr.Fields, 1)
+			assert.Equal(t, "title", validationErr.Fields[0].Field)
+			assert.Equal(t, d.code, validationErr.Fields[0].Code)
+			assert.True(t, errors.Is(err, orders.ErrInvalidOrder))
+			assert.False(t, errors.Is(err, orders.ErrInvalidItem))
+		})
+	}
+}
+
+func TestOrderEngine_CreateOrderAcceptsTitleOfMaxLength(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+
+	_, err := f.engine.CreateOrder(t.Context(), testPrincipal, strings.Repeat("ü", orders.DefaultMaxTitleLength), nil)
+
+	assert.Nil(t, err)
+}
+
+func TestOrderEngine_CreateOrderAppliesConfiguredTitleRules(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	databaseMock := orders_mock.NewMockDatabase(mockCtrl)
+	engine := orders.NewEngine(orders.Config{
+		Database:       databaseMock,
+		MaxTitleLength: 5,
+		TitlePattern:   regexp.MustCompile(`^[a-z ]*$`),
+	})
+	data := []struct {
+		title string
+		code  string
+	}{
+		{"ducks!", orders.CodeTooLong},
+		{"Duck", orders.CodeInvalidCharacters},
+	}
+	for _, d := range data {
+		_, err := engine.CreateOrder(t.Context(), testPrincipal, d.title, nil)
+
+		var validationErr orders.ValidationError
+		require.True(t, errors.As(err, &validationErr), err)
+		assert.Equal(t, []orders.FieldError{{Field: "title", Code: d.code, Message: validationErr.Fields[0].Message}}, validationErr.Fields)
+	}
+}
+
+func TestOrderEngine_CreateOrderReportsAllInvalidFields(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	items := []orders.Item{
+		{SKU: "milk", Quantity: 1, UnitPrice: orders.Money{Amount: 150, Currency: "EUR"}},
+		{SKU: "", Quantity: 0, UnitPrice: orders.Money{Amount: -1, Currency: "USD"}},
+		{SKU: "bread", Quantity: 1, UnitPrice: orders.Money{Amount: 150, Currency: "eur"}},
+	}
+
+	_, err := f.engine.CreateOrder(t.Context(), testPrincipal, "", items)
+
+	var validationErr orders.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	var fields []string
+	var codes []string
+	for _, field := range validationErr.Fields {
+		fields = append(fields, field.Field)
+		codes = append(codes, field.Code)
+	}
+	assert.Equal(t, []string{
+		"title",
+		"items[1].sku",
+		"items[1].quantity",
+		"items[1].unit_price.amount",
+		"items[1].unit_price.currency",
+		"items[2].unit_price.currency",
+	}, fields)
+	assert.Equal(t, []string{
+		orders.CodeRequired,
+		orders.CodeRequired,
+		orders.CodeNotPositive,
+		orders.CodeNegative,
+		orders.CodeCurrencyMismatch,
+		orders.CodeInvalidCurrency,
+	}, codes)
+	assert.True(t, errors.Is(err, orders.ErrInvalidItem))
+}
+
+func TestOrderEngine_UpdateOrderReturnsValidationErrorOnInvalidTitle(t *testing.T) {
+	f := setUpOrdersEngineTest(t)
+	f.databaseMock.EXPECT().
+		GetOrder(gomock.Any(), testTenant, 7).
+		Return(orders.Order{ID: 7, CustomerID: testCustomer, Title: "duck", Status: orders.StatusCreated, Version: 1}, nil)
+	f.databaseMock.EXPECT().
+		UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+	title := ""
+
+	_, err := f.engine.UpdateOrder(t.Context(), testPrincipal, 7, 1, orders.OrderUpdate{Title: &title})
+
+	assert.True(t, errors.Is(err, orders.ErrInvalidOrder), err)
+}